@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"qaBot/internal/bot"
+	"qaBot/pkg/i18n"
+	"strings"
+)
+
+// runExport implements the `export [-format=json|yaml|md|html] [-o file]`
+// subcommand, writing every question to the file or to standard output.
+func runExport(ctx context.Context, repo bot.BotRepository, catalog *i18n.Catalog, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", bot.ExportJSON, "output format: "+strings.Join(bot.ExportFormats, ", "))
+	output := fs.String("o", "", "file to write; standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: export [-format=json|yaml|md|html] [-o file]")
+	}
+
+	langs, err := bot.ExportQuestions(ctx, repo, catalog)
+	if err != nil {
+		return err
+	}
+	if unlisted := bot.UnlistedLanguages(langs); len(unlisted) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: languages missing from the catalog are exported under their code and cannot be imported again until configured: %s\n", strings.Join(unlisted, ", "))
+	}
+	data, err := bot.EncodeExport(*format, langs)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d language(s) to %s\n", len(langs), *output)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"qaBot/internal/bot"
+	"qaBot/pkg/i18n"
+)
+
+// runImport implements the `import [-dry-run] <file>` subcommand. Changes
+// are recorded in the audit log and the revisions with actor 0, like other
+// changes made from the command line.
+func runImport(ctx context.Context, repo bot.BotRepository, catalog *i18n.Catalog, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what the import would change")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: import [-dry-run] <file.yaml|file.json|file.csv>")
+	}
+	name := fs.Arg(0)
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	imp, err := bot.ParseImport(name, data)
+	if err != nil {
+		return describeImportError(catalog, err)
+	}
+	if err := imp.Validate(catalog.Has); err != nil {
+		return describeImportError(catalog, err)
+	}
+
+	if *dryRun {
+		plan, err := imp.Plan(ctx, repo)
+		if err != nil {
+			return describeImportError(catalog, err)
+		}
+		fmt.Println(plan.Describe(catalog, catalog.Default()))
+		return nil
+	}
+
+	plan, err := imp.Apply(ctx, repo, 0, nil)
+	if err != nil {
+		return describeImportError(catalog, err)
+	}
+	fmt.Println(plan.Describe(catalog, catalog.Default()))
+	return nil
+}
+
+// describeImportError lists the problems of an import file in the default
+// language. Other errors are returned as they are.
+func describeImportError(catalog *i18n.Catalog, err error) error {
+	var importErr *bot.ImportError
+	if errors.As(err, &importErr) {
+		return errors.New("invalid import:\n" + importErr.Describe(catalog, catalog.Default()))
+	}
+	return err
+}
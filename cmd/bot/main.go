@@ -2,14 +2,80 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
+	"io/fs"
 	"log"
+	"os"
 	"qaBot/internal/bot"
 	"qaBot/internal/infrastructure/database"
+	"qaBot/locales"
 	"qaBot/pkg/config"
+	"qaBot/pkg/i18n"
 	"time"
 )
 
+const (
+	driverPostgres = database.DialectPostgres
+	driverSQLite   = database.DialectSQLite
+)
+
 func main() {
+	ctx := context.Background() // Create a context
+
+	driver := config.GetString("database.driver")
+	if driver == "" {
+		driver = driverPostgres
+	}
+
+	sqlDB, repo, closeDB := openDatabase(driver)
+	defer closeDB()
+
+	migrator, err := database.NewMigrator(sqlDB, driver)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	// Subcommands, e.g. `qaBot -config=local migrate up` or `qaBot -config=local import faq.yaml`
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(ctx, migrator, args[1:]); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+		case "import":
+			if err := ensureSchema(ctx, migrator); err != nil {
+				log.Fatalf("Error checking database schema: %v", err)
+			}
+			// Running bots may cache the trees the import changes; going
+			// through the cache announces them to be reloaded.
+			cached := bot.NewCachedRepository(repo, openCacheNotifier(driver))
+			if err := runImport(ctx, cached, openCatalog(), args[1:]); err != nil {
+				log.Fatalf("import: %v", err)
+			}
+		case "export":
+			if err := ensureSchema(ctx, migrator); err != nil {
+				log.Fatalf("Error checking database schema: %v", err)
+			}
+			if err := runExport(ctx, repo, openCatalog(), args[1:]); err != nil {
+				log.Fatalf("export: %v", err)
+			}
+		default:
+			log.Fatalf("Unknown command %q", args[0])
+		}
+		return
+	}
+
+	if err := ensureSchema(ctx, migrator); err != nil {
+		log.Fatalf("Error checking database schema: %v", err)
+	}
+
+	if config.GetBool("cache.enabled") {
+		cached := bot.NewCachedRepository(repo, openCacheNotifier(driver))
+		go cached.Listen(ctx)
+		repo = cached
+	}
+
 	// Retrieve configuration values
 	botToken := config.GetString("bot_token")
 	workers := config.GetInt("workers")
@@ -18,32 +84,108 @@ func main() {
 		workers = 1 // Default to 1 worker if not specified or invalid
 	}
 
-	pgCfg := database.PostgresConfig{
-		Host:            config.GetString("database.host"),
-		Port:            config.GetString("database.port"),
-		User:            config.GetString("database.user"),
-		Password:        config.GetString("database.password"),
-		DBName:          config.GetString("database.name"),
-		SSLMode:         config.GetString("database.sslmode"),
-		MaxConns:        int32(config.GetInt("database.max_conns")),
-		MinConns:        int32(config.GetInt("database.min_conns")),
-		MaxConnLifetime: time.Minute * time.Duration(config.GetInt("database.max_conn_lifetime_minutes")),
-	}
-	database.InitializePostgres(pgCfg)
-	defer database.ClosePostgres()
-
-	repo := bot.NewRepository(database.GetPostgresDB())
-
 	// Initialize the bot with the database
-	botAPI, err := bot.NewBot(botToken, repo, workers)
+	botAPI, err := bot.NewBot(botToken, repo, openSessionStore(driver), openCatalog(), workers)
 	if err != nil {
 		log.Fatalf("Error initializing bot: %v", err)
 	}
 
+	if err := botAPI.EnsureOwners(ctx, config.GetInt64s("owner_ids")); err != nil {
+		log.Fatalf("Error granting owner roles: %v", err)
+	}
+
+	if secret := config.GetString("links.secret"); secret != "" {
+		botAPI.SetLinkSecret(secret, config.GetBool("links.accept_plain_ids"))
+	}
+
+	if days := config.GetInt("trash.purge_after_days"); days > 0 {
+		go botAPI.RunTrashPurge(ctx, time.Duration(days)*24*time.Hour, time.Hour)
+	}
+
 	// Start the bot
 	log.Println("Bot is starting...")
-	ctx := context.Background() // Create a context
 	if err := botAPI.Start(ctx); err != nil {
 		log.Fatalf("Error starting bot: %v", err)
 	}
 }
+
+// openDatabase connects to the configured backend and returns a database/sql
+// handle for migrations, the bot repository and a cleanup function.
+func openDatabase(driver string) (*sql.DB, bot.BotRepository, func()) {
+	switch driver {
+	case driverSQLite:
+		database.Initialize(database.SQLiteDSN(config.GetString("database.path")))
+
+		return database.GetDB(), bot.NewSQLiteRepository(database.GetDB()), database.Close
+	case driverPostgres:
+		pgCfg := database.PostgresConfig{
+			Host:            config.GetString("database.host"),
+			Port:            config.GetString("database.port"),
+			User:            config.GetString("database.user"),
+			Password:        config.GetString("database.password"),
+			DBName:          config.GetString("database.name"),
+			SSLMode:         config.GetString("database.sslmode"),
+			MaxConns:        int32(config.GetInt("database.max_conns")),
+			MinConns:        int32(config.GetInt("database.min_conns")),
+			MaxConnLifetime: time.Minute * time.Duration(config.GetInt("database.max_conn_lifetime_minutes")),
+		}
+		database.InitializePostgres(pgCfg)
+
+		return database.GetPostgresSQLDB(), bot.NewRepository(database.GetPostgresDB()), database.ClosePostgres
+	default:
+		log.Fatalf("Unknown database driver %q, expected %q or %q", driver, driverPostgres, driverSQLite)
+		return nil, nil, nil
+	}
+}
+
+// openSessionStore returns the store for admin add/edit sessions selected by
+// sessions.store: "memory" (default) or "database" for the configured backend.
+func openSessionStore(driver string) bot.SessionStore {
+	ttl := time.Minute * time.Duration(config.GetInt("sessions.ttl_minutes"))
+	if ttl <= 0 {
+		ttl = bot.DefaultSessionTTL
+	}
+
+	switch store := config.GetString("sessions.store"); store {
+	case "memory", "":
+		return bot.NewMemorySessionStore(ttl)
+	case "database":
+		if driver == driverSQLite {
+			return bot.NewSQLiteSessionStore(database.GetDB(), ttl)
+		}
+		return bot.NewPostgresSessionStore(database.GetPostgresDB(), ttl)
+	default:
+		log.Fatalf("Unknown session store %q, expected \"memory\" or \"database\"", store)
+		return nil
+	}
+}
+
+// openCacheNotifier returns what keeps the question caches of several bot
+// replicas coherent: LISTEN/NOTIFY with PostgreSQL, nothing with SQLite, whose
+// database file is not shared between hosts.
+func openCacheNotifier(driver string) bot.CacheNotifier {
+	if driver == driverPostgres {
+		return bot.NewPostgresCacheNotifier(database.GetPostgresDB())
+	}
+	return nil
+}
+
+// openCatalog loads the message catalogs of the languages listed in
+// languages.available, from languages.locales_dir or else the embedded
+// locales. Without a list every catalog found is loaded.
+// languages.fallbacks sets the fallback chains.
+func openCatalog() *i18n.Catalog {
+	var fsys fs.FS = locales.FS
+	if dir := config.GetString("languages.locales_dir"); dir != "" {
+		fsys = os.DirFS(dir)
+	}
+
+	catalog, err := i18n.Load(fsys, config.GetStrings("languages.available"), config.GetString("languages.default"))
+	if err != nil {
+		log.Fatalf("Error loading message catalogs: %v", err)
+	}
+	if err := catalog.SetFallbacks(config.GetStringLists("languages.fallbacks")); err != nil {
+		log.Fatalf("Error loading language fallbacks: %v", err)
+	}
+	return catalog
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"qaBot/internal/infrastructure/database"
+	"qaBot/pkg/config"
+	"strconv"
+)
+
+// runMigrate implements the `migrate up|down [steps]|status` subcommand.
+func runMigrate(ctx context.Context, migrator *database.Migrator, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migration(s)", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rolled back %d migration(s)", n)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", cmd)
+	}
+
+	return nil
+}
+
+// ensureSchema applies pending migrations when database.auto_migrate is set,
+// and refuses to start the bot while the schema is behind otherwise.
+func ensureSchema(ctx context.Context, migrator *database.Migrator) error {
+	if config.GetBool("database.auto_migrate") {
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Database schema is up to date (%d migration(s) applied)", n)
+		return nil
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind by %d migration(s), next is %04d_%s; run `migrate up` or set database.auto_migrate",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	return nil
+}
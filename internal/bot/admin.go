@@ -0,0 +1,273 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// HandleAdminCommand implements the owner-only /admin command managing roles and scopes.
+func (b *Bot) HandleAdminCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleAdminCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	actorID := update.Message.From.ID
+	lang := b.userLang(ctx, actorID)
+	if !b.auth.IsOwner(ctx, actorID) {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "admin.owners_only"),
+		})
+		return
+	}
+
+	args := strings.Fields(update.Message.Text)
+	if len(args) < 2 || args[0] != "/admin" {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "admin.usage")})
+		return
+	}
+
+	var text string
+	switch args[1] {
+	case "list":
+		text = b.adminList(ctx, lang)
+	case "add":
+		text = b.adminAdd(ctx, lang, actorID, args[2:])
+	case "remove":
+		text = b.adminRemove(ctx, lang, actorID, args[2:])
+	case "scope":
+		text = b.adminScope(ctx, lang, actorID, args[2:])
+	case "scopes":
+		text = b.adminScopes(ctx, lang, args[2:])
+	case "unscope":
+		text = b.adminUnscope(ctx, lang, actorID, args[2:])
+	default:
+		text = b.t(lang, "admin.usage")
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: text})
+}
+
+func (b *Bot) adminList(ctx context.Context, lang string) string {
+	admins, err := b.repository.ListAdmins(ctx)
+	if err != nil {
+		return b.t(lang, "admin.list_failed")
+	}
+	if len(admins) == 0 {
+		return b.t(lang, "admin.none")
+	}
+
+	lines := []string{b.t(lang, "admin.list")}
+	for _, a := range admins {
+		lines = append(lines, fmt.Sprintf("%d — %s", a.UserID, a.Role))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) adminAdd(ctx context.Context, lang string, actorID int64, args []string) string {
+	if len(args) < 1 || len(args) > 2 {
+		return b.t(lang, "admin.usage")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return b.t(lang, "admin.invalid_user")
+	}
+
+	role := RoleEditor
+	if len(args) == 2 {
+		if role, err = ParseRole(args[1]); err != nil {
+			return b.t(lang, "admin.unknown_role")
+		}
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		auth := NewAuthService(tx)
+		previous := auth.Role(ctx, userID)
+		if err := auth.SetRole(ctx, userID, role); err != nil || previous == role {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: actorID, Action: AuditRoleSet, TargetUserID: userID},
+			roleChange{Role: previous}, roleChange{Role: role})
+	})
+	if err != nil {
+		if errors.Is(err, ErrLastOwner) {
+			return b.t(lang, "admin.last_owner_demote")
+		}
+		return b.t(lang, "admin.save_failed")
+	}
+	return b.t(lang, "admin.role_set", userID, role)
+}
+
+func (b *Bot) adminRemove(ctx context.Context, lang string, actorID int64, args []string) string {
+	if len(args) != 1 {
+		return b.t(lang, "admin.usage")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return b.t(lang, "admin.invalid_user")
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		auth := NewAuthService(tx)
+		previous := auth.Role(ctx, userID)
+		if err := auth.RemoveAdmin(ctx, userID); err != nil || previous == "" {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: actorID, Action: AuditRoleRemove, TargetUserID: userID},
+			roleChange{Role: previous}, nil)
+	})
+	if err != nil {
+		if errors.Is(err, ErrLastOwner) {
+			return b.t(lang, "admin.last_owner_remove")
+		}
+		return b.t(lang, "admin.remove_failed")
+	}
+	return b.t(lang, "admin.removed", userID)
+}
+
+// adminScope limits an editor to a language and/or the subtree of a question.
+func (b *Bot) adminScope(ctx context.Context, lang string, actorID int64, args []string) string {
+	if len(args) < 3 || len(args) > 4 {
+		return b.t(lang, "admin.usage")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return b.t(lang, "admin.invalid_user")
+	}
+	if b.auth.Role(ctx, userID) != RoleEditor {
+		return b.t(lang, "admin.editors_only")
+	}
+
+	scope := Scope{UserID: userID, Actions: allActions}
+	if args[1] != "*" {
+		scope.Lang = args[1]
+	}
+
+	if scope.RootID, err = strconv.Atoi(args[2]); err != nil || scope.RootID < 0 {
+		return b.t(lang, "admin.invalid_root")
+	}
+	if scope.RootID != 0 {
+		if _, err := b.repository.GetQuestionShallow(ctx, scope.RootID); err != nil {
+			return b.t(lang, "common.question_not_found", scope.RootID)
+		}
+	}
+
+	if len(args) == 4 {
+		if scope.Actions, err = ParseActions(args[3]); err != nil {
+			return b.t(lang, "admin.unknown_action")
+		}
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		var err error
+		if scope.ID, err = tx.AddAdminScope(ctx, scope); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: actorID, Action: AuditScopeAdd, QuestionID: scope.RootID, TargetUserID: userID}, nil, scope)
+	})
+	if err != nil {
+		return b.t(lang, "admin.scope_failed")
+	}
+	return b.t(lang, "admin.scope_added", b.describeScope(lang, scope))
+}
+
+func (b *Bot) adminScopes(ctx context.Context, lang string, args []string) string {
+	var userID int64
+	if len(args) == 1 {
+		var err error
+		if userID, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return b.t(lang, "admin.invalid_user")
+		}
+	}
+
+	scopes, err := b.repository.ListAdminScopes(ctx, userID)
+	if err != nil {
+		return b.t(lang, "admin.scopes_failed")
+	}
+	if len(scopes) == 0 {
+		return b.t(lang, "admin.no_scopes")
+	}
+
+	lines := []string{b.t(lang, "admin.scopes")}
+	for _, s := range scopes {
+		lines = append(lines, b.describeScope(lang, s))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) adminUnscope(ctx context.Context, lang string, actorID int64, args []string) string {
+	if len(args) != 1 {
+		return b.t(lang, "admin.usage")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return b.t(lang, "admin.invalid_scope")
+	}
+
+	// Look the scope up first so the audit log keeps what was removed
+	var removed *Scope
+	if scopes, err := b.repository.ListAdminScopes(ctx, 0); err == nil {
+		for i := range scopes {
+			if scopes[i].ID == id {
+				removed = &scopes[i]
+			}
+		}
+	}
+	if removed == nil {
+		return b.t(lang, "admin.scope_not_found", id)
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		// An editor without scopes is unrestricted, so the last scope of an
+		// editor must not be removed on its own
+		role, err := tx.GetAdminRole(ctx, removed.UserID)
+		if err != nil {
+			return err
+		}
+		scopes, err := tx.ListAdminScopes(ctx, removed.UserID)
+		if err != nil {
+			return err
+		}
+		if role == RoleEditor && len(scopes) <= 1 {
+			return ErrLastScope
+		}
+
+		if err := tx.DeleteAdminScope(ctx, id); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: actorID, Action: AuditScopeRemove, QuestionID: removed.RootID, TargetUserID: removed.UserID},
+			removed, nil)
+	})
+	if errors.Is(err, ErrLastScope) {
+		return b.t(lang, "admin.last_scope", id, removed.UserID, removed.UserID)
+	}
+	if err != nil {
+		return b.t(lang, "admin.unscope_failed")
+	}
+	return b.t(lang, "admin.unscoped", id)
+}
+
+// describeScope renders a scope as listed by /admin scopes.
+func (b *Bot) describeScope(lang string, s Scope) string {
+	scopeLang, root := s.Lang, b.t(lang, "admin.whole_tree")
+	if scopeLang == "" {
+		scopeLang = b.t(lang, "admin.any_language")
+	}
+	if s.RootID != 0 {
+		root = b.t(lang, "admin.subtree", s.RootID)
+	}
+	return b.t(lang, "admin.scope", s.ID, s.UserID, scopeLang, root, joinActions(s.Actions))
+}
@@ -0,0 +1,269 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// AuditAction names a change recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditQuestionCreate    AuditAction = "question.create"
+	AuditQuestionEdit      AuditAction = "question.edit"
+	AuditQuestionFile      AuditAction = "question.file"
+	AuditQuestionDelete    AuditAction = "question.delete"
+	AuditQuestionRestore   AuditAction = "question.restore"
+	AuditQuestionRevert    AuditAction = "question.revert"
+	AuditQuestionReorder   AuditAction = "question.reorder"
+	AuditQuestionMove      AuditAction = "question.move"
+	AuditQuestionImport    AuditAction = "question.import"
+	AuditRoleSet           AuditAction = "role.set"
+	AuditRoleRemove        AuditAction = "role.remove"
+	AuditScopeAdd          AuditAction = "scope.add"
+	AuditScopeRemove       AuditAction = "scope.remove"
+	AuditSynonymAdd        AuditAction = "synonym.add"
+	AuditSynonymRemove     AuditAction = "synonym.remove"
+	AuditTranslationLink   AuditAction = "translation.link"
+	AuditTranslationUnlink AuditAction = "translation.unlink"
+)
+
+const (
+	// maxListedAudit limits the entries shown by /audit without an export format.
+	maxListedAudit = 20
+
+	auditDateLayout = "2006-01-02"
+)
+
+// AuditEntry is one row of the append-only audit log. Before and After hold
+// JSON snapshots of the changed object; either may be empty.
+type AuditEntry struct {
+	ID           int64       `json:"id"`
+	ActorID      int64       `json:"actor_id"`
+	Action       AuditAction `json:"action"`
+	QuestionID   int         `json:"question_id,omitempty"`
+	TargetUserID int64       `json:"target_user_id,omitempty"`
+	Before       string      `json:"before,omitempty"`
+	After        string      `json:"after,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// AuditFilter narrows ListAuditEntries. Zero values disable a condition; To is exclusive.
+type AuditFilter struct {
+	ActorID int64
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// roleChange is the audit payload of role changes.
+type roleChange struct {
+	Role Role `json:"role"`
+}
+
+// recordAudit appends an entry with before/after snapshots; nil snapshots are left empty.
+// Failures are logged and never abort the audited change.
+func (b *Bot) recordAudit(ctx context.Context, entry AuditEntry, before, after any) {
+	if err := addAuditEntry(ctx, b.repository, entry, before, after); err != nil {
+		log.Printf("Failed to record audit entry %s by user %d: %v", entry.Action, entry.ActorID, err)
+	}
+}
+
+// addAuditEntry is recordAudit for use inside WithTx, where a failure rolls
+// back the change being recorded.
+func addAuditEntry(ctx context.Context, repo BotRepository, entry AuditEntry, before, after any) error {
+	entry.Before = auditPayload(before)
+	entry.After = auditPayload(after)
+	return repo.AddAuditEntry(ctx, entry)
+}
+
+func auditPayload(v any) string {
+	if v == nil {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode audit payload: %v", err)
+		return ""
+	}
+	return string(raw)
+}
+
+// HandleAuditCommand implements the owner-only /audit command.
+func (b *Bot) HandleAuditCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleAuditCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	lang := b.userLang(ctx, update.Message.From.ID)
+	if !b.auth.IsOwner(ctx, update.Message.From.ID) {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "audit.owners_only"),
+		})
+		return
+	}
+
+	filter, format, err := parseAuditArgs(strings.Fields(update.Message.Text)[1:])
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "audit.usage")})
+		return
+	}
+	if format == "" {
+		filter.Limit = maxListedAudit
+	}
+
+	entries, err := b.repository.ListAuditEntries(ctx, filter)
+	if err != nil {
+		log.Println("failed to list audit entries: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "audit.failed")})
+		return
+	}
+	if len(entries) == 0 {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "audit.none")})
+		return
+	}
+
+	if format == "" {
+		lines := []string{b.t(lang, "audit.title")}
+		for _, e := range entries {
+			lines = append(lines, b.auditSummary(lang, e))
+		}
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   truncateText(strings.Join(lines, "\n"), maxMessageLength),
+		})
+		return
+	}
+
+	var data []byte
+	switch format {
+	case "csv":
+		data, err = auditCSV(entries)
+	case "json":
+		data, err = auditJSON(entries)
+	}
+	if err != nil {
+		log.Println("failed to export audit entries: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "audit.export_failed")})
+		return
+	}
+
+	_, err = tbot.SendDocument(ctx, &tgbot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &models.InputFileUpload{
+			Filename: fmt.Sprintf("audit_%s.%s", time.Now().UTC().Format("20060102_150405"), format),
+			Data:     bytes.NewReader(data),
+		},
+		Caption: b.t(lang, "audit.caption", len(entries)),
+	})
+	if err != nil {
+		log.Println("failed to send audit export: ", err)
+	}
+}
+
+func parseAuditArgs(args []string) (AuditFilter, string, error) {
+	var (
+		filter AuditFilter
+		format string
+	)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "csv", "json":
+			format = args[i]
+			continue
+		case "user", "from", "to":
+		default:
+			return filter, "", fmt.Errorf("unknown argument %q", args[i])
+		}
+
+		if i+1 == len(args) {
+			return filter, "", fmt.Errorf("missing value for %q", args[i])
+		}
+		key, value := args[i], args[i+1]
+		i++
+
+		var err error
+		switch key {
+		case "user":
+			filter.ActorID, err = strconv.ParseInt(value, 10, 64)
+		case "from":
+			filter.From, err = time.Parse(auditDateLayout, value)
+		case "to":
+			// The whole "to" day is included
+			filter.To, err = time.Parse(auditDateLayout, value)
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+		if err != nil {
+			return filter, "", err
+		}
+	}
+	return filter, format, nil
+}
+
+func (b *Bot) auditSummary(lang string, e AuditEntry) string {
+	var sb strings.Builder
+	sb.WriteString(b.t(lang, "audit.entry", e.ID, e.CreatedAt.Format("2006-01-02 15:04"), e.Action, e.ActorID))
+	if e.QuestionID != 0 {
+		sb.WriteString(b.t(lang, "audit.question", e.QuestionID))
+	}
+	if e.TargetUserID != 0 {
+		sb.WriteString(b.t(lang, "audit.user", e.TargetUserID))
+	}
+	return sb.String()
+}
+
+func auditCSV(entries []AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "created_at", "actor_id", "action", "question_id", "target_user_id", "before", "after"})
+	for _, e := range entries {
+		w.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(e.ActorID, 10),
+			string(e.Action),
+			strconv.Itoa(e.QuestionID),
+			strconv.FormatInt(e.TargetUserID, 10),
+			e.Before,
+			e.After,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func auditJSON(entries []AuditEntry) ([]byte, error) {
+	// Snapshots are embedded as JSON rather than as quoted strings
+	type exported struct {
+		AuditEntry
+		Before json.RawMessage `json:"before,omitempty"`
+		After  json.RawMessage `json:"after,omitempty"`
+	}
+
+	out := make([]exported, 0, len(entries))
+	for _, e := range entries {
+		x := exported{AuditEntry: e}
+		if e.Before != "" {
+			x.Before = json.RawMessage(e.Before)
+		}
+		if e.After != "" {
+			x.After = json.RawMessage(e.After)
+		}
+		out = append(out, x)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,289 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Role is an admin role stored in the admins table.
+type Role string
+
+const (
+	// RoleViewer can read question history, the trash, search gaps, synonyms
+	// and missing translations, but change nothing.
+	RoleViewer Role = "viewer"
+	// RoleEditor can add, edit and delete questions.
+	RoleEditor Role = "editor"
+	// RoleOwner can do everything an editor can and manage other admins.
+	RoleOwner Role = "owner"
+)
+
+// Action is an editing right that can be limited by a Scope.
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionEdit   Action = "edit"
+	ActionDelete Action = "delete"
+)
+
+var allActions = []Action{ActionAdd, ActionEdit, ActionDelete}
+
+// Scope limits an editor's actions to a language and/or to the subtree rooted
+// at RootID. Empty Lang and zero RootID mean "any".
+type Scope struct {
+	ID      int      `json:"id"`
+	UserID  int64    `json:"user_id"`
+	Lang    string   `json:"lang"`
+	RootID  int      `json:"root_id"`
+	Actions []Action `json:"actions"`
+}
+
+// allows reports whether the scope grants action on a question in lang whose
+// path (the question ID and all its ancestors) is given.
+func (s Scope) allows(action Action, lang string, path []int) bool {
+	if !slices.Contains(s.Actions, action) {
+		return false
+	}
+	if s.Lang != "" && s.Lang != lang {
+		return false
+	}
+	return s.RootID == 0 || slices.Contains(path, s.RootID)
+}
+
+// ParseActions parses a comma separated list such as "add,edit".
+func ParseActions(s string) ([]Action, error) {
+	var actions []Action
+	for _, part := range strings.Split(s, ",") {
+		action := Action(strings.TrimSpace(part))
+		if !slices.Contains(allActions, action) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownAction, action)
+		}
+		if !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+func joinActions(actions []Action) string {
+	parts := make([]string, len(actions))
+	for i, a := range actions {
+		parts[i] = string(a)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitActions(s string) []Action {
+	actions, err := ParseActions(s)
+	if err != nil {
+		log.Printf("Ignoring invalid scope actions %q: %v", s, err)
+	}
+	return actions
+}
+
+// Permissions are the effective editing rights of one user.
+type Permissions struct {
+	role   Role
+	scopes []Scope
+}
+
+// CanView reports whether the user may use the read-only admin features.
+func (p *Permissions) CanView() bool {
+	return p != nil && p.role.level() >= RoleViewer.level()
+}
+
+// CanEdit reports whether the user has any editing rights at all.
+func (p *Permissions) CanEdit() bool {
+	return p != nil && p.role.level() >= RoleEditor.level()
+}
+
+// Allows reports whether the user may perform action on a question in lang.
+// path holds the question ID followed by its ancestors; when adding, it holds
+// the future parent and its ancestors. Owners and editors without scopes are
+// unrestricted; scoped editors need at least one matching scope.
+func (p *Permissions) Allows(action Action, lang string, path []int) bool {
+	if !p.CanEdit() {
+		return false
+	}
+	if p.role == RoleOwner || len(p.scopes) == 0 {
+		return true
+	}
+	for _, s := range p.scopes {
+		if s.allows(action, lang, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin is a user with an assigned role.
+type Admin struct {
+	UserID    int64     `json:"user_id"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// level orders roles so that a higher role includes the rights of the lower ones.
+func (r Role) level() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if role.level() == 0 {
+		return "", ErrUnknownRole
+	}
+	return role, nil
+}
+
+// AuthService answers every authorization question asked by the handlers.
+type AuthService struct {
+	repo BotRepository
+}
+
+func NewAuthService(repo BotRepository) *AuthService {
+	return &AuthService{repo: repo}
+}
+
+// Role returns the user's role, or "" if the user is not an admin or the lookup fails.
+func (a *AuthService) Role(ctx context.Context, userID int64) Role {
+	role, err := a.repo.GetAdminRole(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get role for user %d: %v", userID, err)
+		return ""
+	}
+	return role
+}
+
+// HasRole reports whether the user holds at least the given role.
+func (a *AuthService) HasRole(ctx context.Context, userID int64, min Role) bool {
+	return a.Role(ctx, userID).level() >= min.level()
+}
+
+// Permissions loads the user's role and scopes. Users without editing rights
+// get permissions that allow nothing, without the scope lookup.
+func (a *AuthService) Permissions(ctx context.Context, userID int64) *Permissions {
+	p := &Permissions{role: a.Role(ctx, userID)}
+	if !p.CanEdit() || p.role == RoleOwner {
+		return p
+	}
+
+	scopes, err := a.repo.ListAdminScopes(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get scopes for user %d: %v", userID, err)
+		// Fail closed: an unreadable scope list must not widen the rights.
+		return &Permissions{}
+	}
+	p.scopes = scopes
+	return p
+}
+
+// Can reports whether the user may perform action on the question.
+func (a *AuthService) Can(ctx context.Context, userID int64, action Action, q *Question) bool {
+	p := a.Permissions(ctx, userID)
+	if !p.CanEdit() {
+		return false
+	}
+
+	path, err := a.repo.GetQuestionPath(ctx, q.ID)
+	if err != nil {
+		log.Printf("Failed to get path of question %d: %v", q.ID, err)
+		return false
+	}
+	return p.Allows(action, q.Lang, path)
+}
+
+// CanAdd reports whether the user may add a question in lang under parentID.
+func (a *AuthService) CanAdd(ctx context.Context, userID int64, lang string, parentID int) bool {
+	p := a.Permissions(ctx, userID)
+	if !p.CanEdit() {
+		return false
+	}
+
+	var path []int
+	if parentID != 0 {
+		var err error
+		if path, err = a.repo.GetQuestionPath(ctx, parentID); err != nil {
+			log.Printf("Failed to get path of question %d: %v", parentID, err)
+			return false
+		}
+	}
+	return p.Allows(ActionAdd, lang, path)
+}
+
+// IsOwner reports whether the user may manage other admins.
+func (a *AuthService) IsOwner(ctx context.Context, userID int64) bool {
+	return a.HasRole(ctx, userID, RoleOwner)
+}
+
+// EnsureOwners grants the owner role to the given users, so a fresh database
+// always has someone who can manage admins.
+func (a *AuthService) EnsureOwners(ctx context.Context, userIDs []int64) error {
+	for _, id := range userIDs {
+		if a.Role(ctx, id) == RoleOwner {
+			continue
+		}
+		if err := a.repo.SetAdminRole(ctx, id, RoleOwner); err != nil {
+			return err
+		}
+		log.Printf("Granted owner role to user %d", id)
+	}
+	return nil
+}
+
+// SetRole grants a role to the user, refusing to demote the last owner.
+func (a *AuthService) SetRole(ctx context.Context, userID int64, role Role) error {
+	if role != RoleOwner {
+		last, err := a.isLastOwner(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if last {
+			return ErrLastOwner
+		}
+	}
+	return a.repo.SetAdminRole(ctx, userID, role)
+}
+
+// RemoveAdmin revokes the user's role, refusing to remove the last owner.
+func (a *AuthService) RemoveAdmin(ctx context.Context, userID int64) error {
+	last, err := a.isLastOwner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if last {
+		return ErrLastOwner
+	}
+	return a.repo.RemoveAdmin(ctx, userID)
+}
+
+func (a *AuthService) isLastOwner(ctx context.Context, userID int64) (bool, error) {
+	admins, err := a.repo.ListAdmins(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	owners, isOwner := 0, false
+	for _, adm := range admins {
+		if adm.Role == RoleOwner {
+			owners++
+			isOwner = isOwner || adm.UserID == userID
+		}
+	}
+	return isOwner && owners == 1, nil
+}
@@ -0,0 +1,410 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CacheNotifier tells other bot replicas sharing the database that cached
+// questions changed. An empty language stands for every language.
+type CacheNotifier interface {
+	Notify(ctx context.Context, lang string) error
+	// Listen calls invalidate for every change announced by other replicas
+	// until ctx is done.
+	Listen(ctx context.Context, invalidate func(lang string))
+}
+
+// CachedRepository is a read-through cache in front of a BotRepository. It
+// keeps the question tree of every language that was asked for in memory and
+// serves GetQuestionsByLang, GetQuestionByID, GetQuestionShallow and
+// GetSubQuestions from it.
+// Changes to questions drop the trees of the languages they touch.
+//
+// The cached questions are shared between callers and must not be modified.
+type CachedRepository struct {
+	BotRepository
+	notifier CacheNotifier
+
+	mu      sync.RWMutex
+	trees   map[string]*questionTree // language -> tree
+	version uint64                   // bumped by every invalidation
+
+	// changes collects what a transaction changed, to be invalidated once
+	// it is over; nil outside WithTx
+	changes *cacheChanges
+}
+
+type cacheChanges struct {
+	langs []string
+	all   bool
+}
+
+// questionTree is the cached tree of one language.
+type questionTree struct {
+	roots []Question
+	byID  map[int]*Question
+}
+
+// NewCachedRepository wraps repo in a cache. notifier may be nil when the bot
+// runs as a single replica.
+func NewCachedRepository(repo BotRepository, notifier CacheNotifier) *CachedRepository {
+	return &CachedRepository{
+		BotRepository: repo,
+		notifier:      notifier,
+		trees:         make(map[string]*questionTree),
+	}
+}
+
+// Listen applies the invalidations announced by other replicas until ctx is
+// done. It does nothing without a notifier.
+func (r *CachedRepository) Listen(ctx context.Context) {
+	if r.notifier == nil {
+		return
+	}
+	r.notifier.Listen(ctx, r.invalidate)
+}
+
+func (r *CachedRepository) GetQuestionsByLang(ctx context.Context, lang string) ([]Question, error) {
+	tree, err := r.tree(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+	return tree.roots, nil
+}
+
+func (r *CachedRepository) GetQuestionByID(ctx context.Context, id int) (*Question, error) {
+	if q, ok := r.cached(id); ok {
+		return &q, nil
+	}
+	return r.BotRepository.GetQuestionByID(ctx, id)
+}
+
+func (r *CachedRepository) GetQuestionShallow(ctx context.Context, id int) (*Question, error) {
+	if q, ok := r.cached(id); ok {
+		q.SubQuestions = nil
+		return &q, nil
+	}
+	return r.BotRepository.GetQuestionShallow(ctx, id)
+}
+
+func (r *CachedRepository) GetSubQuestions(ctx context.Context, parentID int) ([]Question, error) {
+	if q, ok := r.cached(parentID); ok {
+		return q.SubQuestions, nil
+	}
+	return r.BotRepository.GetSubQuestions(ctx, parentID)
+}
+
+// tree returns the cached tree of a language, loading it when missing.
+func (r *CachedRepository) tree(ctx context.Context, lang string) (*questionTree, error) {
+	r.mu.RLock()
+	tree, ok := r.trees[lang]
+	version := r.version
+	r.mu.RUnlock()
+	if ok {
+		return tree, nil
+	}
+
+	roots, err := r.BotRepository.GetQuestionsByLang(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+	tree = &questionTree{roots: roots, byID: make(map[int]*Question)}
+	tree.index(tree.roots)
+
+	// A tree loaded while questions changed may already be stale
+	r.mu.Lock()
+	if r.version == version {
+		r.trees[lang] = tree
+	}
+	r.mu.Unlock()
+	return tree, nil
+}
+
+func (t *questionTree) index(questions []Question) {
+	for i := range questions {
+		t.byID[questions[i].ID] = &questions[i]
+		t.index(questions[i].SubQuestions)
+	}
+}
+
+// cached returns a copy of a question from the cached trees.
+func (r *CachedRepository) cached(id int) (Question, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tree := range r.trees {
+		if q, ok := tree.byID[id]; ok {
+			return *q, true
+		}
+	}
+	return Question{}, false
+}
+
+// treeLangs returns the languages of the trees holding the given questions,
+// skipping zero IDs. A tree is keyed by the language of its top-level
+// question, so questions that are not cached are looked up through their root,
+// since other replicas may cache them. known is false when one of them could
+// not be looked up and every tree must be dropped.
+func (r *CachedRepository) treeLangs(ctx context.Context, ids ...int) (langs []string, known bool) {
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		lang, ok := r.treeLang(ctx, id)
+		if !ok {
+			return nil, false
+		}
+		langs = append(langs, lang)
+	}
+	return langs, true
+}
+
+func (r *CachedRepository) treeLang(ctx context.Context, id int) (string, bool) {
+	r.mu.RLock()
+	for lang, tree := range r.trees {
+		if _, ok := tree.byID[id]; ok {
+			r.mu.RUnlock()
+			return lang, true
+		}
+	}
+	r.mu.RUnlock()
+
+	path, err := r.BotRepository.GetQuestionPath(ctx, id)
+	if err != nil || len(path) == 0 {
+		return "", false
+	}
+	root, err := r.BotRepository.GetQuestionShallow(ctx, path[len(path)-1])
+	if err != nil {
+		return "", false
+	}
+	return root.Lang, true
+}
+
+// changedTrees invalidates the languages found by treeLangs and the extra
+// ones, or every language when treeLangs could not tell.
+func (r *CachedRepository) changedTrees(ctx context.Context, langs []string, known bool, extra ...string) {
+	if !known {
+		r.changedAll(ctx)
+		return
+	}
+	r.changed(ctx, append(langs, extra...)...)
+}
+
+// invalidate drops the cached tree of a language, or every tree when lang is
+// empty.
+func (r *CachedRepository) invalidate(lang string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.version++
+	if lang == "" {
+		r.trees = make(map[string]*questionTree)
+		return
+	}
+	delete(r.trees, lang)
+}
+
+// WithTx runs fn in a transaction of the wrapped repository. Inside it the
+// cache only holds what the transaction itself read, and the languages it
+// changed are invalidated everywhere once it is over.
+func (r *CachedRepository) WithTx(ctx context.Context, fn func(tx BotRepository) error) error {
+	if r.changes != nil {
+		return fn(r)
+	}
+
+	changes := &cacheChanges{}
+	err := r.BotRepository.WithTx(ctx, func(tx BotRepository) error {
+		return fn(&CachedRepository{BotRepository: tx, trees: make(map[string]*questionTree), changes: changes})
+	})
+
+	if changes.all {
+		r.changedAll(ctx)
+	} else {
+		r.changed(ctx, changes.langs...)
+	}
+	return err
+}
+
+// changed invalidates the given languages here and on the other replicas.
+// Empty languages are skipped.
+func (r *CachedRepository) changed(ctx context.Context, langs ...string) {
+	if r.changes != nil {
+		r.changes.langs = append(r.changes.langs, langs...)
+		for _, lang := range langs {
+			if lang != "" {
+				r.invalidate(lang)
+			}
+		}
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, lang := range langs {
+		if lang == "" || seen[lang] {
+			continue
+		}
+		seen[lang] = true
+
+		r.invalidate(lang)
+		if r.notifier != nil {
+			if err := r.notifier.Notify(ctx, lang); err != nil {
+				log.Printf("Failed to announce question cache invalidation: %v", err)
+			}
+		}
+	}
+}
+
+// changedAll invalidates every language here and on the other replicas.
+func (r *CachedRepository) changedAll(ctx context.Context) {
+	r.invalidate("")
+	if r.changes != nil {
+		r.changes.all = true
+		return
+	}
+	if r.notifier != nil {
+		if err := r.notifier.Notify(ctx, ""); err != nil {
+			log.Printf("Failed to announce question cache invalidation: %v", err)
+		}
+	}
+}
+
+func (r *CachedRepository) CreateQuestion(ctx context.Context, lang, text, answer, fileType, fileID string, parentID int) (int, error) {
+	langs, known := r.treeLangs(ctx, parentID)
+	id, err := r.BotRepository.CreateQuestion(ctx, lang, text, answer, fileType, fileID, parentID)
+	r.changedTrees(ctx, langs, known, lang)
+	return id, err
+}
+
+func (r *CachedRepository) UpdateQuestion(ctx context.Context, id int, text, answer string) error {
+	langs, known := r.treeLangs(ctx, id)
+	err := r.BotRepository.UpdateQuestion(ctx, id, text, answer)
+	r.changedTrees(ctx, langs, known)
+	return err
+}
+
+func (r *CachedRepository) UpdateQuestionFile(ctx context.Context, id int, fileType, fileID string) error {
+	langs, known := r.treeLangs(ctx, id)
+	err := r.BotRepository.UpdateQuestionFile(ctx, id, fileType, fileID)
+	r.changedTrees(ctx, langs, known)
+	return err
+}
+
+func (r *CachedRepository) DeleteQuestionByID(ctx context.Context, id int, deletedBy int64) error {
+	langs, known := r.treeLangs(ctx, id)
+	err := r.BotRepository.DeleteQuestionByID(ctx, id, deletedBy)
+	r.changedTrees(ctx, langs, known)
+	return err
+}
+
+func (r *CachedRepository) SwapQuestions(ctx context.Context, id, otherID int) error {
+	langs, known := r.treeLangs(ctx, id, otherID)
+	err := r.BotRepository.SwapQuestions(ctx, id, otherID)
+	r.changedTrees(ctx, langs, known)
+	return err
+}
+
+// MoveQuestion also invalidates the question's own language when it is moved
+// to the top level, where it starts a tree of that language.
+func (r *CachedRepository) MoveQuestion(ctx context.Context, id, parentID int) error {
+	langs, known := r.treeLangs(ctx, id, parentID)
+	if parentID == 0 && known {
+		if q, err := r.BotRepository.GetQuestionShallow(ctx, id); err == nil {
+			langs = append(langs, q.Lang)
+		} else {
+			known = false
+		}
+	}
+	err := r.BotRepository.MoveQuestion(ctx, id, parentID)
+	r.changedTrees(ctx, langs, known)
+	return err
+}
+
+// RestoreQuestion drops every tree: the restored subtree is not cached, and
+// restores are rare enough not to look up where it goes.
+func (r *CachedRepository) RestoreQuestion(ctx context.Context, id int) error {
+	err := r.BotRepository.RestoreQuestion(ctx, id)
+	r.changedAll(ctx)
+	return err
+}
+
+// LinkTranslations and UnlinkTranslation renumber whole translation groups,
+// which span languages, so they drop every tree.
+func (r *CachedRepository) LinkTranslations(ctx context.Context, id, otherID int) error {
+	err := r.BotRepository.LinkTranslations(ctx, id, otherID)
+	r.changedAll(ctx)
+	return err
+}
+
+func (r *CachedRepository) UnlinkTranslation(ctx context.Context, id int) error {
+	err := r.BotRepository.UnlinkTranslation(ctx, id)
+	r.changedAll(ctx)
+	return err
+}
+
+// questionCacheChannel is the PostgreSQL channel cache invalidations are
+// announced on.
+const questionCacheChannel = "qabot_question_cache"
+
+// PostgresCacheNotifier announces cache invalidations with NOTIFY and receives
+// those of other replicas with LISTEN.
+type PostgresCacheNotifier struct {
+	db         *pgxpool.Pool
+	retryDelay time.Duration
+}
+
+func NewPostgresCacheNotifier(db *pgxpool.Pool) *PostgresCacheNotifier {
+	return &PostgresCacheNotifier{db: db, retryDelay: 5 * time.Second}
+}
+
+func (n *PostgresCacheNotifier) Notify(ctx context.Context, lang string) error {
+	_, err := n.db.Exec(ctx, "SELECT pg_notify($1, $2)", questionCacheChannel, lang)
+	return err
+}
+
+// Listen holds one pooled connection for LISTEN. When the connection is lost
+// every language is invalidated, since announcements may have been missed,
+// and listening resumes after a delay.
+func (n *PostgresCacheNotifier) Listen(ctx context.Context, invalidate func(lang string)) {
+	for {
+		err := n.listen(ctx, invalidate)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Question cache listener stopped: %v", err)
+		invalidate("")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(n.retryDelay):
+		}
+	}
+}
+
+func (n *PostgresCacheNotifier) listen(ctx context.Context, invalidate func(lang string)) error {
+	conn, err := n.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is closed rather than returned, so that it does not go
+	// back to the pool still listening
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+questionCacheChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		invalidate(notification.Payload)
+	}
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// confirmTTL is how long Confirm buttons stay valid.
+	confirmTTL = 2 * time.Minute
+
+	// maxListedDescendants limits the titles shown in a delete confirmation.
+	maxListedDescendants = 10
+)
+
+// stampCallback appends the current time to callback data, so that the
+// button can be rejected once confirmTTL has passed.
+func stampCallback(data string) string {
+	return fmt.Sprintf("%s_%d", data, time.Now().Unix())
+}
+
+// parseStampedCallback splits data built by stampCallback into the original
+// data and whether the stamp has expired.
+func parseStampedCallback(data string) (base string, expired bool, ok bool) {
+	i := strings.LastIndex(data, "_")
+	if i < 0 {
+		return "", false, false
+	}
+
+	stamp, err := strconv.ParseInt(data[i+1:], 10, 64)
+	if err != nil {
+		return "", false, false
+	}
+
+	return data[:i], time.Since(time.Unix(stamp, 0)) > confirmTTL, true
+}
+
+// describeDeletion lists what a delete of q will remove, including the cascade.
+func (b *Bot) describeDeletion(lang string, q *Question, descendants []Question) string {
+	var sb strings.Builder
+	sb.WriteString(b.t(lang, "delete.prompt", q.ID, q.Text))
+
+	if len(descendants) == 0 {
+		sb.WriteString("\n\n" + b.t(lang, "delete.no_children"))
+		return sb.String()
+	}
+
+	sb.WriteString("\n\n" + b.t(lang, "delete.children", len(descendants)))
+	for i, d := range descendants {
+		if i == maxListedDescendants {
+			sb.WriteString("\n" + b.t(lang, "common.more", len(descendants)-maxListedDescendants))
+			break
+		}
+		fmt.Fprintf(&sb, "\n• #%d %s", d.ID, d.Text)
+	}
+	return sb.String()
+}
+
+// HandleDeleteConfirm deletes a question after the admin confirmed it in time.
+func (b *Bot) HandleDeleteConfirm(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleDeleteConfirm received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	base, expired, ok := parseStampedCallback(update.CallbackQuery.Data)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(base, "delok_"))
+	if err != nil {
+		return
+	}
+
+	// Remove the Confirm/Cancel buttons either way
+	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
+		ChatID:    chatID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+
+	lang := b.userLang(ctx, userID)
+	if expired {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "delete.expired"),
+		})
+		return
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionDelete, q) {
+		return
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.DeleteQuestionByID(ctx, id, userID); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionDelete, QuestionID: id}, q, nil)
+	})
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "delete.failed"),
+		})
+		return
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   b.t(lang, "delete.done", id),
+	})
+}
+
+// HandleDeleteCancel dismisses a delete confirmation.
+func (b *Bot) HandleDeleteCancel(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            b.t(b.userLang(ctx, update.CallbackQuery.From.ID), "delete.cancelled"),
+	})
+
+	tbot.DeleteMessage(ctx, &tgbot.DeleteMessageParams{
+		ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+}
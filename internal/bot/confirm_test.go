@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseStampedCallback(t *testing.T) {
+	stamped := func(data string, age time.Duration) string {
+		return fmt.Sprintf("%s_%d", data, time.Now().Add(-age).Unix())
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		base    string
+		expired bool
+		ok      bool
+	}{
+		{"fresh", stampCallback("delc_12"), "delc_12", false, true},
+		{"unexpired", stamped("delc_12", confirmTTL-10*time.Second), "delc_12", false, true},
+		{"expired", stamped("delc_12", confirmTTL+10*time.Second), "delc_12", true, true},
+		// Buttons sent before stamps existed end in the question ID, which reads
+		// as a stamp from 1970
+		{"no stamp", "delc_12", "delc", true, true},
+		{"no separator", "delc", "", false, false},
+		{"empty stamp", "delc_12_", "", false, false},
+		{"text stamp", "delc_12_abc", "", false, false},
+		{"fractional stamp", "delc_12_1.5", "", false, false},
+		{"empty data", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, expired, ok := parseStampedCallback(tt.data)
+			if base != tt.base || expired != tt.expired || ok != tt.ok {
+				t.Fatalf("parseStampedCallback(%q) = %q, %v, %v; want %q, %v, %v",
+					tt.data, base, expired, ok, tt.base, tt.expired, tt.ok)
+			}
+		})
+	}
+}
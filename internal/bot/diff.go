@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"strings"
+)
+
+// maxDiffCells bounds the LCS table; larger inputs are shown as a full replacement.
+const maxDiffCells = 4_000_000
+
+// diffWords returns a word-level diff of a and b, marking removed words as
+// [-word-] and added words as {+word+}.
+func diffWords(a, b string) string {
+	if a == b {
+		return a
+	}
+
+	x, y := strings.Fields(a), strings.Fields(b)
+
+	// Common prefix and suffix need no LCS
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	var out []string
+	out = append(out, x[:prefix]...)
+	out = append(out, diffMiddle(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	out = append(out, x[len(x)-suffix:]...)
+
+	return strings.Join(out, " ")
+}
+
+func diffMiddle(x, y []string) []string {
+	if (len(x)+1)*(len(y)+1) > maxDiffCells {
+		return append(removed(x), added(y)...)
+	}
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		out      []string
+		del, ins []string
+	)
+	flush := func() {
+		out = append(out, removed(del)...)
+		out = append(out, added(ins)...)
+		del, ins = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			flush()
+			out = append(out, x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			del = append(del, x[i])
+			i++
+		default:
+			ins = append(ins, y[j])
+			j++
+		}
+	}
+	del = append(del, x[i:]...)
+	ins = append(ins, y[j:]...)
+	flush()
+
+	return out
+}
+
+func removed(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	return []string{"[-" + strings.Join(words, " ") + "-]"}
+}
+
+func added(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	return []string{"{+" + strings.Join(words, " ") + "+}"}
+}
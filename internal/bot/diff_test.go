@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"unchanged", "the right to life", "the right to life", "the right to life"},
+		{"from empty", "", "the right to life", "{+the right to life+}"},
+		{"to empty", "the right to life", "", "[-the right to life-]"},
+		{"insertion", "the right to life", "the inherent right to life", "the {+inherent+} right to life"},
+		{"insertion at the end", "the right", "the right to life", "the right {+to life+}"},
+		{"deletion", "the inherent right to life", "the right to life", "the [-inherent-] right to life"},
+		{"deletion at the start", "article 6 the right to life", "the right to life", "[-article 6-] the right to life"},
+		{"replacement", "the right to life", "the right to liberty", "the right to [-life-] {+liberty+}"},
+		{"repeated words", "no no no", "no no", "no no [-no-]"},
+		{"repeated words inserted", "a b a", "a b a b a", "a b a {+b a+}"},
+		{"moved word", "life and liberty", "liberty and life", "[-life and-] liberty {+and life+}"},
+		{"whitespace is not a change", "the  right", "the right", "the right"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffWords(tt.a, tt.b); got != tt.want {
+				t.Fatalf("diffWords(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffWordsLargeInput(t *testing.T) {
+	// Beyond maxDiffCells the changed middle is shown as a full replacement
+	a := strings.Repeat("a ", 2500) + "end"
+	b := strings.Repeat("b ", 2500) + "end"
+	got := diffWords(a, b)
+	if !strings.HasPrefix(got, "[-a a ") || !strings.Contains(got, "a-] {+b b ") || !strings.HasSuffix(got, "b+} end") {
+		t.Fatalf("diffWords() = %.40q..., want a replacement of the differing words", got)
+	}
+}
@@ -0,0 +1,300 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"qaBot/pkg/i18n"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/goccy/go-yaml"
+)
+
+// Export formats. JSON and YAML can be imported again, see Import; Markdown
+// and HTML are documents for people to read.
+const (
+	ExportJSON     = "json"
+	ExportYAML     = "yaml"
+	ExportMarkdown = "md"
+	ExportHTML     = "html"
+)
+
+// ExportFormats lists the formats EncodeExport supports.
+var ExportFormats = []string{ExportJSON, ExportYAML, ExportMarkdown, ExportHTML}
+
+// ExportLanguage is the question tree of one language.
+type ExportLanguage struct {
+	Code string
+	Name string
+	// Unlisted is set for languages that have questions but are missing from
+	// the catalog. They are named by their code, and the file cannot be
+	// imported again until they are configured.
+	Unlisted  bool
+	Questions []Question
+}
+
+// ExportQuestions loads the question trees of the catalog's languages and of
+// any other language the database has questions in, skipping languages
+// without questions.
+func ExportQuestions(ctx context.Context, repo BotRepository, catalog *i18n.Catalog) ([]ExportLanguage, error) {
+	stored, err := repo.GetQuestionLanguages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list question languages: %w", err)
+	}
+
+	var langs []ExportLanguage
+	for _, l := range catalog.Languages() {
+		langs = append(langs, ExportLanguage{Code: l.Code, Name: l.Name})
+	}
+	for _, code := range stored {
+		if !catalog.Has(code) {
+			langs = append(langs, ExportLanguage{Code: code, Name: code, Unlisted: true})
+		}
+	}
+
+	var out []ExportLanguage
+	for _, lang := range langs {
+		questions, err := repo.GetQuestionsByLang(ctx, lang.Code)
+		if err != nil {
+			return nil, fmt.Errorf("load %s questions: %w", lang.Code, err)
+		}
+		if len(questions) == 0 {
+			continue
+		}
+		lang.Questions = questions
+		out = append(out, lang)
+	}
+	return out, nil
+}
+
+// UnlistedLanguages returns the codes of the exported languages that are
+// missing from the catalog.
+func UnlistedLanguages(langs []ExportLanguage) []string {
+	var codes []string
+	for _, lang := range langs {
+		if lang.Unlisted {
+			codes = append(codes, lang.Code)
+		}
+	}
+	return codes
+}
+
+// EncodeExport renders exported questions in one of ExportFormats.
+func EncodeExport(format string, langs []ExportLanguage) ([]byte, error) {
+	switch format {
+	case ExportJSON:
+		return exportJSON(exportedQuestions(langs))
+	case ExportYAML:
+		return yaml.MarshalWithOptions(exportedQuestions(langs), yaml.UseLiteralStyleIfMultiline(true))
+	case ExportMarkdown:
+		return exportMarkdown(langs), nil
+	case ExportHTML:
+		return exportHTML(langs)
+	default:
+		return nil, fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// exportedQuestions joins the trees of all languages into the list of
+// top-level questions the importer reads. Translation links are left out,
+// since the importer does not restore them.
+func exportedQuestions(langs []ExportLanguage) []Question {
+	questions := []Question{}
+	for _, lang := range langs {
+		questions = append(questions, withoutTranslationLinks(lang.Questions)...)
+	}
+	return questions
+}
+
+func withoutTranslationLinks(questions []Question) []Question {
+	out := make([]Question, len(questions))
+	for i, q := range questions {
+		q.TranslationGroup = 0
+		q.SubQuestions = withoutTranslationLinks(q.SubQuestions)
+		out[i] = q
+	}
+	return out
+}
+
+// exportJSON indents the questions and leaves HTML characters unescaped,
+// for people editing the file before importing it again.
+func exportJSON(questions []Question) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(questions); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportMarkdown renders the trees with one heading level per question level,
+// down to the deepest heading Markdown has.
+func exportMarkdown(langs []ExportLanguage) []byte {
+	var sb strings.Builder
+	sb.WriteString("# Questions\n")
+
+	var write func(questions []Question, level int)
+	write = func(questions []Question, level int) {
+		for _, q := range questions {
+			fmt.Fprintf(&sb, "\n%s %s\n\n%s\n", strings.Repeat("#", min(level, 6)), q.Text, q.Answer)
+			if q.FileType != "" {
+				fmt.Fprintf(&sb, "\n_Attachment: %s_\n", describeAttachment(q.FileType))
+			}
+			write(q.SubQuestions, level+1)
+		}
+	}
+	for _, lang := range langs {
+		fmt.Fprintf(&sb, "\n## %s\n", lang.Name)
+		write(lang.Questions, 3)
+	}
+	return []byte(sb.String())
+}
+
+var exportHTMLTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"heading":    func(level int) int { return min(level, 6) },
+	"next":       func(level int) int { return level + 1 },
+	"attachment": describeAttachment,
+	"tree": func(questions []Question, level int) map[string]any {
+		return map[string]any{"Questions": questions, "Level": level}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Questions</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+section section { margin-left: 1.5em; }
+.answer { white-space: pre-wrap; }
+.attachment { font-style: italic; color: #666; }
+</style>
+</head>
+<body>
+<h1>Questions</h1>
+{{- range .}}
+<section>
+<h2>{{.Name}}</h2>
+{{- template "tree" (tree .Questions 3)}}
+</section>
+{{- end}}
+</body>
+</html>
+{{define "tree"}}{{$level := .Level}}{{range .Questions}}
+<section>
+<h{{heading $level}}>{{.Text}}</h{{heading $level}}>
+<p class="answer">{{.Answer}}</p>
+{{- if .FileType}}
+<p class="attachment">Attachment: {{attachment .FileType}}</p>
+{{- end}}
+{{- template "tree" (tree .SubQuestions (next $level))}}
+</section>
+{{- end}}{{end}}`))
+
+func exportHTML(langs []ExportLanguage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := exportHTMLTemplate.Execute(&buf, langs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func describeAttachment(fileType string) string {
+	switch fileType {
+	case fileTypeDoc:
+		return "document"
+	case fileTypePhoto:
+		return "photo"
+	}
+	return fileType
+}
+
+// HandleExportCommand implements the owner-only /export command.
+func (b *Bot) HandleExportCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleExportCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	lang := b.userLang(ctx, update.Message.From.ID)
+	if !b.auth.IsOwner(ctx, update.Message.From.ID) {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "export.owners_only"),
+		})
+		return
+	}
+
+	format := ExportJSON
+	switch args := strings.Fields(update.Message.Text)[1:]; len(args) {
+	case 0:
+	case 1:
+		format = strings.ToLower(args[0])
+	default:
+		format = ""
+	}
+	if !slices.Contains(ExportFormats, format) {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "export.usage")})
+		return
+	}
+
+	langs, err := ExportQuestions(ctx, b.repository, b.catalog)
+	var data []byte
+	if err == nil {
+		data, err = EncodeExport(format, langs)
+	}
+	if err != nil {
+		log.Println("failed to export questions: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "export.failed")})
+		return
+	}
+
+	_, err = tbot.SendDocument(ctx, &tgbot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &models.InputFileUpload{
+			Filename: fmt.Sprintf("questions_%s.%s", time.Now().UTC().Format("20060102_150405"), format),
+			Data:     bytes.NewReader(data),
+		},
+		Caption: b.describeExport(lang, langs),
+	})
+	if err != nil {
+		log.Println("failed to send question export: ", err)
+	}
+}
+
+// describeExport counts the exported questions per language.
+func (b *Bot) describeExport(lang string, langs []ExportLanguage) string {
+	if len(langs) == 0 {
+		return b.t(lang, "export.empty")
+	}
+
+	var count func(questions []Question) int
+	count = func(questions []Question) int {
+		n := len(questions)
+		for _, q := range questions {
+			n += count(q.SubQuestions)
+		}
+		return n
+	}
+
+	parts := make([]string, 0, len(langs))
+	for _, l := range langs {
+		parts = append(parts, fmt.Sprintf("%s: %d", l.Code, count(l.Questions)))
+	}
+	caption := b.t(lang, "export.caption", strings.Join(parts, ", "))
+	if unlisted := UnlistedLanguages(langs); len(unlisted) > 0 {
+		caption += "\n\n" + b.t(lang, "export.unlisted", strings.Join(unlisted, ", "))
+	}
+	return caption
+}
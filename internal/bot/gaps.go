@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"qaBot/pkg/textsearch"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// maxListedGaps limits the queries shown by /gaps.
+	maxListedGaps = 10
+
+	defaultGapDays = 30
+)
+
+// UnansweredQuery is a normalized search that found nothing. ID refers to its
+// latest occurrence.
+type UnansweredQuery struct {
+	ID    int64  `json:"id"`
+	Lang  string `json:"lang"`
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// recordUnanswered logs a query that found nothing, normalized so that
+// different spellings of the same words are counted together.
+func (b *Bot) recordUnanswered(ctx context.Context, lang, query string) {
+	normalized := strings.Join(textsearch.Words(query), " ")
+	if normalized == "" {
+		return
+	}
+	if err := b.repository.RecordUnansweredQuery(ctx, lang, normalized); err != nil {
+		log.Printf("Failed to record unanswered query: %v", err)
+	}
+}
+
+// HandleGaps implements /gaps, the report of unanswered searches. Each gap the
+// user may add a question for comes with a button doing so.
+func (b *Bot) HandleGaps(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleGaps received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	perms := b.auth.Permissions(ctx, update.Message.From.ID)
+	if !perms.CanView() {
+		return
+	}
+
+	lang := b.userLang(ctx, update.Message.From.ID)
+	days, gapLang := defaultGapDays, ""
+	for _, arg := range strings.Fields(update.Message.Text)[1:] {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			days = n
+		} else if b.catalog.Has(arg) {
+			gapLang = arg
+		} else {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "gaps.usage")})
+			return
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	gaps, err := b.repository.ListUnansweredQueries(ctx, since, gapLang, maxListedGaps)
+	if err != nil {
+		log.Println("failed to list unanswered queries: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "gaps.failed")})
+		return
+	}
+	if len(gaps) == 0 {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "gaps.none", days),
+		})
+		return
+	}
+
+	lines := []string{b.t(lang, "gaps.title", days)}
+	var rows [][]models.InlineKeyboardButton
+	for i, g := range gaps {
+		lines = append(lines, fmt.Sprintf("%d. [%s] %s — %d×", i+1, g.Lang, g.Query, g.Count))
+		if perms.Allows(ActionAdd, g.Lang, nil) {
+			rows = append(rows, []models.InlineKeyboardButton{
+				{Text: fmt.Sprintf("➕ %d. %s", i+1, g.Query), CallbackData: fmt.Sprintf("gap_%d", g.ID)},
+			})
+		}
+	}
+
+	params := &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   truncateText(strings.Join(lines, "\n"), maxMessageLength),
+	}
+	if len(rows) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+	tbot.SendMessage(ctx, params)
+}
+
+// HandleGapCallback starts the add-question wizard for an unanswered query,
+// with the query filled in as the question text.
+func (b *Bot) HandleGapCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleGapCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(update.CallbackQuery.Data, "gap_"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+
+	gap, err := b.repository.GetUnansweredQuery(ctx, id)
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(b.userLang(ctx, userID), "gaps.answered")})
+		return
+	}
+
+	if !b.auth.CanAdd(ctx, userID, gap.Lang, 0) {
+		return
+	}
+
+	b.startWizard(ctx, tbot, chatID, userID, &PendingQuestionData{
+		Lang: gap.Lang,
+		Text: gap.Query,
+		Gap:  gap.Query,
+	})
+}
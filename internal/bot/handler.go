@@ -6,21 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbot "github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/go-telegram/ui/keyboard/reply"
 )
 
-var (
-	adminIDs = map[int64]bool{
-		687353891: true,
-		767885674: true,
-	}
-)
-
 const (
 	pageSize = 5
 
@@ -29,20 +24,40 @@ const (
 )
 
 type Question struct {
-	ID           int        `json:"id"`
-	Lang         string     `json:"lang"`
-	Text         string     `json:"text"`
-	Answer       string     `json:"answer"`
-	FileType     string     `json:"file_type"`
-	FileID       string     `json:"file_id"`
-	ParentID     int        `json:"parent_id"`
+	ID       int    `json:"id"`
+	Lang     string `json:"lang"`
+	Text     string `json:"text"`
+	Answer   string `json:"answer"`
+	FileType string `json:"file_type"`
+	FileID   string `json:"file_id"`
+	ParentID int    `json:"parent_id"`
+	// TranslationGroup ties the versions of a question in other languages
+	// together; 0 when it has none.
+	TranslationGroup int `json:"translation_group,omitempty"`
+	// Version grows with every change of the text, answer or attachment.
+	Version      int        `json:"version,omitempty"`
 	SubQuestions []Question `json:"sub_questions,omitempty"`
 }
 
 type PendingQuestionData struct {
-	ParentID int
-	Lang     string
-	EditID   *int // nil if adding
+	ParentID int    `json:"parent_id"`
+	Lang     string `json:"lang"`
+	EditID   *int   `json:"edit_id,omitempty"` // nil if adding
+	Version  int    `json:"version,omitempty"` // of the edited question when the edit started
+	// ConflictVersion is the version shown to the admin when saving the edit
+	// conflicted; Overwrite saves over that version only
+	ConflictVersion int        `json:"conflict_version,omitempty"`
+	Step            WizardStep `json:"step"`
+	Text            string     `json:"text"`
+	Answer          string     `json:"answer"`
+	FileType        string     `json:"file_type"`
+	FileID          string     `json:"file_id"`
+	Gap             string     `json:"gap,omitempty"` // unanswered query the new question answers
+	ExpiresAt       time.Time  `json:"expires_at"`
+}
+
+func (d *PendingQuestionData) expired() bool {
+	return !d.ExpiresAt.IsZero() && time.Now().After(d.ExpiresAt)
 }
 
 func (b *Bot) GetStart(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
@@ -50,30 +65,15 @@ func (b *Bot) GetStart(ctx context.Context, tbot *tgbot.Bot, update *models.Upda
 		return
 	}
 
-	lang, err := b.repository.GetUserLang(ctx, update.Message.From.ID)
-	if err != nil || lang == "" {
-		lang = "en"
+	// Deep links arrive as "/start <payload>"
+	if args := strings.Fields(update.Message.Text); len(args) > 1 && b.HandleStartLink(ctx, tbot, update, args[1]) {
+		return
 	}
 
-	commands := map[string][]string{
-		"en": {
-			"Distinguished experts of the Human Rights Committee, here you can find all the texts of the Legal Acts and current statistics on all questions submitted",
-			"Available commands:",
-			"/start - Show this help message",
-			"/questions - List available questions",
-			"/language - Set language",
-		},
+	// Skipped by detectLanguage in case the payload was a link
+	b.ensureUserLang(ctx, update.Message.From, "")
 
-		"ru": {
-			"Уважаемые эксперты Комитета по правам человека, здесь вы можете найти все тексты Нормативных Актов и текущую статистику по всем вопросам, которые были направлены",
-			"Доступные команды:",
-			"/start - Показать это сообщение",
-			"/questions - Список доступных вопросов",
-			"/language - Установить язык",
-		},
-	}
-
-	msg := strings.Join(commands[lang], "\n")
+	msg := b.t(b.userLang(ctx, update.Message.From.ID), "start.help")
 
 	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
@@ -88,41 +88,121 @@ func (b *Bot) GetQuestions(ctx context.Context, tbot *tgbot.Bot, update *models.
 
 	fmt.Printf("GetQuestions called by user %d in %d\n", update.Message.From.ID, update.Message.Chat.ID)
 
-	isAdmin := adminIDs[update.Message.From.ID]
+	lang := b.userLang(ctx, update.Message.From.ID)
 
-	questions, err := b.getQuestionsByUserID(ctx, update.Message.From.ID)
+	questions, shown, err := b.getQuestionsByUserID(ctx, update.Message.From.ID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "No questions available.",
+			Text:   b.t(lang, "questions.none"),
 		})
 		return
 	}
 
-	keyboard := b.buildQuestionKeyboard(questions, 0, 0, pageSize, isAdmin)
+	access := b.questionAccess(ctx, update.Message.From.ID, 0)
+	keyboard := b.buildQuestionKeyboard(lang, questions, 0, 0, pageSize, access)
 
 	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
-		Text:        "Choose a question:",
+		Text:        b.chooseQuestionText(lang, shown),
 		ReplyMarkup: keyboard,
 	})
 }
 
-func (b *Bot) getQuestionsByUserID(ctx context.Context, userID int64) ([]Question, error) {
-	lang, err := b.repository.GetUserLang(ctx, userID)
-	if err != nil || lang == "" {
-		lang = "en"
+// getQuestionsByUserID returns the top-level questions in the user's language
+// or, while there are none, in the next language of its fallback chain that
+// has some, together with the language they are in.
+func (b *Bot) getQuestionsByUserID(ctx context.Context, userID int64) ([]Question, string, error) {
+	return b.chainQuestions(ctx, b.userLang(ctx, userID))
+}
+
+// chainQuestions returns the top-level questions of the first language in the
+// fallback chain of lang that has any, together with that language.
+func (b *Bot) chainQuestions(ctx context.Context, lang string) ([]Question, string, error) {
+	for _, l := range b.catalog.Chain(lang) {
+		questions, err := b.repository.GetQuestionsByLang(ctx, l)
+		if err != nil {
+			return []Question{}, lang, err
+		}
+		if len(questions) > 0 {
+			return questions, l, nil
+		}
 	}
+	return []Question{}, lang, nil
+}
 
-	questions, err := b.repository.GetQuestionsByLang(ctx, lang)
-	if err != nil {
-		return []Question{}, err
+// chooseQuestionText is the title of the top-level question list, marked
+// when the questions are in a fallback language.
+func (b *Bot) chooseQuestionText(lang, shown string) string {
+	text := b.t(lang, "questions.choose")
+	if notice := b.fallbackNotice(lang, shown); notice != "" {
+		text = notice + "\n\n" + text
+	}
+	return text
+}
+
+// questionAccess describes which admin buttons buildQuestionKeyboard may show
+// for the questions under one parent. A nil access shows none.
+type questionAccess struct {
+	perms      *Permissions
+	lang       string // language new questions are created in
+	parentPath []int  // parent ID followed by its ancestors, empty at the root
+}
+
+// questionAccess loads the user's permissions for the level under parentID,
+// or returns nil for users who are not admins.
+func (b *Bot) questionAccess(ctx context.Context, userID int64, parentID int) *questionAccess {
+	perms := b.auth.Permissions(ctx, userID)
+	if !perms.CanView() {
+		return nil
 	}
-	return questions, nil
 
+	lang := b.questionLang(ctx, userID, parentID)
+
+	var path []int
+	if parentID != 0 {
+		var err error
+		if path, err = b.repository.GetQuestionPath(ctx, parentID); err != nil {
+			log.Printf("Failed to get path of question %d: %v", parentID, err)
+			return nil
+		}
+	}
+
+	return &questionAccess{perms: perms, lang: lang, parentPath: path}
+}
+
+// questionLang returns the language of the questions below parentID: that of
+// the parent, which may differ from the user's when it was opened from a link,
+// or the user's language at the top level.
+func (b *Bot) questionLang(ctx context.Context, userID int64, parentID int) string {
+	if parentID != 0 {
+		if parent, err := b.repository.GetQuestionShallow(ctx, parentID); err == nil {
+			return parent.Lang
+		}
+	}
+
+	return b.userLang(ctx, userID)
+}
+
+func (a *questionAccess) can(action Action, q Question) bool {
+	if a == nil {
+		return false
+	}
+	return a.perms.Allows(action, q.Lang, append([]int{q.ID}, a.parentPath...))
+}
+
+func (a *questionAccess) canView() bool {
+	return a != nil && a.perms.CanView()
+}
+
+func (a *questionAccess) canAdd() bool {
+	if a == nil {
+		return false
+	}
+	return a.perms.Allows(ActionAdd, a.lang, a.parentPath)
 }
 
-func (b *Bot) buildQuestionKeyboard(questions []Question, parentID, page, pageSize int, isAdmin bool) *models.InlineKeyboardMarkup {
+func (b *Bot) buildQuestionKeyboard(lang string, questions []Question, parentID, page, pageSize int, access *questionAccess) *models.InlineKeyboardMarkup {
 	var rows [][]models.InlineKeyboardButton
 
 	// Фильтруем по родителю
@@ -141,25 +221,53 @@ func (b *Bot) buildQuestionKeyboard(questions []Question, parentID, page, pageSi
 	}
 	pageQuestions := filtered[start:end]
 
-	for _, q := range pageQuestions {
+	for i, q := range pageQuestions {
 		rows = append(rows, []models.InlineKeyboardButton{
 			{
 				Text:         q.Text,
 				CallbackData: fmt.Sprintf("q_%d", q.ID),
 			},
 		})
-		if isAdmin {
-			rows = append(rows,
-				[]models.InlineKeyboardButton{
-					{
-						Text:         "✏️",
-						CallbackData: fmt.Sprintf("edit_%d", q.ID),
-					},
-					{
-						Text:         "🗑️",
-						CallbackData: fmt.Sprintf("del_%d", q.ID),
-					},
+		var adminRow []models.InlineKeyboardButton
+		canEdit := access.can(ActionEdit, q)
+		if canEdit {
+			adminRow = append(adminRow, models.InlineKeyboardButton{
+				Text:         "✏️",
+				CallbackData: fmt.Sprintf("edit_%d", q.ID),
+			})
+		}
+		if access.canView() {
+			adminRow = append(adminRow, models.InlineKeyboardButton{
+				Text:         "🕘",
+				CallbackData: fmt.Sprintf("hist_%d", q.ID),
+			})
+		}
+		if canEdit {
+			if start+i > 0 {
+				adminRow = append(adminRow, models.InlineKeyboardButton{
+					Text:         "⬆️",
+					CallbackData: fmt.Sprintf("up_%d", q.ID),
+				})
+			}
+			if start+i < total-1 {
+				adminRow = append(adminRow, models.InlineKeyboardButton{
+					Text:         "⬇️",
+					CallbackData: fmt.Sprintf("down_%d", q.ID),
 				})
+			}
+			adminRow = append(adminRow, models.InlineKeyboardButton{
+				Text:         "↪️",
+				CallbackData: fmt.Sprintf("mv_%d", q.ID),
+			})
+		}
+		if access.can(ActionDelete, q) {
+			adminRow = append(adminRow, models.InlineKeyboardButton{
+				Text:         "🗑️",
+				CallbackData: fmt.Sprintf("del_%d", q.ID),
+			})
+		}
+		if len(adminRow) > 0 {
+			rows = append(rows, adminRow)
 		}
 	}
 
@@ -167,13 +275,13 @@ func (b *Bot) buildQuestionKeyboard(questions []Question, parentID, page, pageSi
 	var navRow []models.InlineKeyboardButton
 	if page > 0 {
 		navRow = append(navRow, models.InlineKeyboardButton{
-			Text:         "⬅️ Prev",
+			Text:         b.t(lang, "questions.prev"),
 			CallbackData: fmt.Sprintf("p_%d_%d", parentID, page-1),
 		})
 	}
 	if end < total {
 		navRow = append(navRow, models.InlineKeyboardButton{
-			Text:         "➡️ Next",
+			Text:         b.t(lang, "questions.next"),
 			CallbackData: fmt.Sprintf("p_%d_%d", parentID, page+1),
 		})
 	}
@@ -185,17 +293,21 @@ func (b *Bot) buildQuestionKeyboard(questions []Question, parentID, page, pageSi
 	if parentID != 0 {
 		rows = append(rows, []models.InlineKeyboardButton{
 			{
-				Text:         "🔙 Back",
+				Text:         b.t(lang, "questions.back"),
 				CallbackData: fmt.Sprintf("back_%d", parentID),
 			},
+			{
+				Text:         b.t(lang, "questions.share"),
+				CallbackData: fmt.Sprintf("share_%d", parentID),
+			},
 		})
 	}
 
-	// Add "Add Question" button for admins at the root level
-	if isAdmin {
+	// Add "Add Question" button for admins allowed to add at this level
+	if access.canAdd() {
 		rows = append(rows, []models.InlineKeyboardButton{
 			{
-				Text:         "➕ Add Question",
+				Text:         b.t(lang, "questions.add"),
 				CallbackData: fmt.Sprintf("add_question_%d", parentID),
 			},
 		})
@@ -213,8 +325,6 @@ func (b *Bot) HandleQuestionCallback(ctx context.Context, tbot *tgbot.Bot, updat
 
 	fmt.Printf("HandleQuestionCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
-	isAdmin := adminIDs[update.CallbackQuery.From.ID]
-
 	data := update.CallbackQuery.Data
 
 	id, err := strconv.Atoi(strings.TrimPrefix(data, "q_"))
@@ -227,8 +337,6 @@ func (b *Bot) HandleQuestionCallback(ctx context.Context, tbot *tgbot.Bot, updat
 		return
 	}
 
-	keyboard := b.buildQuestionKeyboard(q.SubQuestions, q.ID, 0, pageSize, isAdmin)
-
 	chatID := update.CallbackQuery.Message.Message.Chat.ID
 	msgID := update.CallbackQuery.Message.Message.ID
 
@@ -238,6 +346,17 @@ func (b *Bot) HandleQuestionCallback(ctx context.Context, tbot *tgbot.Bot, updat
 		MessageID: msgID,
 	})
 
+	b.sendQuestion(ctx, tbot, chatID, update.CallbackQuery.From.ID, q)
+}
+
+// sendQuestion sends the file and answer of a question with the keyboard of
+// its sub-questions.
+func (b *Bot) sendQuestion(ctx context.Context, tbot *tgbot.Bot, chatID, userID int64, q *Question) {
+	lang := b.userLang(ctx, userID)
+	access := b.questionAccess(ctx, userID, q.ID)
+	keyboard := b.buildQuestionKeyboard(lang, q.SubQuestions, q.ID, 0, pageSize, access)
+	b.addTranslationsButton(lang, keyboard, q)
+
 	// Step 2: Send file if available
 	if q.FileType == fileTypeDoc {
 		_, _ = tbot.SendDocument(ctx, &tgbot.SendDocumentParams{
@@ -256,14 +375,29 @@ func (b *Bot) HandleQuestionCallback(ctx context.Context, tbot *tgbot.Bot, updat
 	}
 
 	// Step 3: Send question text and answer
+	text := formatAnswer(q)
+	if b.shownAsFallback(ctx, lang, q) {
+		text = b.fallbackNotice(lang, q.Lang) + "\n\n" + text
+	}
 	_, _ = tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 		ChatID:      chatID,
-		Text:        fmt.Sprintf("*%s*\n\n%s", q.Text, q.Answer),
+		Text:        text,
 		ParseMode:   "Markdown",
 		ReplyMarkup: keyboard,
 	})
 }
 
+// shownAsFallback reports whether q is shown because the user's language has
+// no questions and its fallback chain led to q's language. Questions reached
+// otherwise, e.g. through a link or a search, are just in another language.
+func (b *Bot) shownAsFallback(ctx context.Context, lang string, q *Question) bool {
+	if q.Lang == lang || !slices.Contains(b.catalog.Chain(lang), q.Lang) {
+		return false
+	}
+	_, shown, err := b.chainQuestions(ctx, lang)
+	return err == nil && shown == q.Lang
+}
+
 func (b *Bot) HandleQuestionPageCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
 	if update.CallbackQuery == nil {
 		return
@@ -272,7 +406,6 @@ func (b *Bot) HandleQuestionPageCallback(ctx context.Context, tbot *tgbot.Bot, u
 	fmt.Printf("HandleQuestionPageCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
 	userID := update.CallbackQuery.From.ID
-	isAdmin := adminIDs[userID]
 
 	data := update.CallbackQuery.Data
 
@@ -283,20 +416,27 @@ func (b *Bot) HandleQuestionPageCallback(ctx context.Context, tbot *tgbot.Bot, u
 	parentID, _ := strconv.Atoi(parts[1])
 	page, _ := strconv.Atoi(parts[2])
 
-	questions, err := b.getQuestionsByUserID(ctx, userID)
-	if err != nil {
-		return
-	}
-
+	var (
+		questions []Question
+		parentQ   *Question
+		err       error
+	)
 	if parentID != 0 {
-		parentQ, err := b.repository.GetQuestionByID(ctx, parentID)
+		parentQ, err = b.repository.GetQuestionByID(ctx, parentID)
 		if err != nil {
 			return
 		}
 		questions = parentQ.SubQuestions
+	} else if questions, _, err = b.getQuestionsByUserID(ctx, userID); err != nil {
+		return
 	}
 
-	keyboard := b.buildQuestionKeyboard(questions, parentID, page, pageSize, isAdmin)
+	access := b.questionAccess(ctx, userID, parentID)
+	lang := b.userLang(ctx, userID)
+	keyboard := b.buildQuestionKeyboard(lang, questions, parentID, page, pageSize, access)
+	if parentQ != nil {
+		b.addTranslationsButton(lang, keyboard, parentQ)
+	}
 
 	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
@@ -312,7 +452,7 @@ func (b *Bot) HandleQuestionBackCallback(ctx context.Context, tbot *tgbot.Bot, u
 
 	fmt.Printf("HandleQuestionBackCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
-	isAdmin := adminIDs[update.CallbackQuery.From.ID]
+	userID := update.CallbackQuery.From.ID
 	data := update.CallbackQuery.Data
 
 	childID, _ := strconv.Atoi(strings.TrimPrefix(data, "back_"))
@@ -320,21 +460,24 @@ func (b *Bot) HandleQuestionBackCallback(ctx context.Context, tbot *tgbot.Bot, u
 		return
 	}
 
-	currentQ, err := b.repository.GetQuestionByID(ctx, childID)
+	currentQ, err := b.repository.GetQuestionShallow(ctx, childID)
 	if err != nil {
 		return
 	}
 
+	lang := b.userLang(ctx, userID)
+
 	if currentQ.ParentID == 0 {
-		questions, err := b.getQuestionsByUserID(ctx, update.CallbackQuery.From.ID)
+		questions, shown, err := b.getQuestionsByUserID(ctx, userID)
 		if err != nil {
 			return
 		}
-		keyboard := b.buildQuestionKeyboard(questions, 0, 0, pageSize, isAdmin)
+		access := b.questionAccess(ctx, userID, 0)
+		keyboard := b.buildQuestionKeyboard(lang, questions, 0, 0, pageSize, access)
 		tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{
 			ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
 			MessageID:   update.CallbackQuery.Message.Message.ID,
-			Text:        "Choose a question:",
+			Text:        b.chooseQuestionText(lang, shown),
 			ParseMode:   "Markdown",
 			ReplyMarkup: keyboard,
 		})
@@ -346,17 +489,35 @@ func (b *Bot) HandleQuestionBackCallback(ctx context.Context, tbot *tgbot.Bot, u
 		return
 	}
 
-	keyboard := b.buildQuestionKeyboard(parentQ.SubQuestions, parentQ.ID, 0, pageSize, isAdmin)
+	access := b.questionAccess(ctx, userID, parentQ.ID)
+	keyboard := b.buildQuestionKeyboard(lang, parentQ.SubQuestions, parentQ.ID, 0, pageSize, access)
+	b.addTranslationsButton(lang, keyboard, parentQ)
 
 	tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
 		MessageID:   update.CallbackQuery.Message.Message.ID,
-		Text:        "Choose a question:",
+		Text:        b.t(lang, "questions.choose"),
 		ParseMode:   "Markdown",
 		ReplyMarkup: keyboard,
 	})
 }
 
+// userLang returns the language the user reads the bot in: their choice when
+// it is still configured, else the first configured language of its fallback
+// chain.
+func (b *Bot) userLang(ctx context.Context, userID int64) string {
+	lang, err := b.repository.GetUserLang(ctx, userID)
+	if err != nil {
+		return b.catalog.Default()
+	}
+	return b.catalog.Chain(lang)[0]
+}
+
+// t returns a message from the catalog, see i18n.Catalog.T.
+func (b *Bot) t(lang, key string, args ...any) string {
+	return b.catalog.T(lang, key, args...)
+}
+
 func (b *Bot) HandleLanguage(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
@@ -368,41 +529,44 @@ func (b *Bot) HandleLanguage(ctx context.Context, tbot *tgbot.Bot, update *model
 		reply.IsOneTimeKeyboard(),
 	)
 
-	replyKeyboard = replyKeyboard.
-		Button("English", tbot, tgbot.MatchTypeExact, b.HandleLanguageSelection).
-		Button("Русский", tbot, tgbot.MatchTypeExact, b.HandleLanguageSelection)
+	// Selections are handled by HandleLanguageSelection, registered once in Start
+	prompts := make([]string, 0, len(b.catalog.Languages()))
+	for _, l := range b.catalog.Languages() {
+		replyKeyboard = replyKeyboard.Button(l.Name, tbot, tgbot.MatchTypeExact, nil)
+		prompts = append(prompts, b.t(l.Code, "language_prompt"))
+	}
 
 	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
-		Text:        "Please choose your language / Пожалуйста, выберите язык:",
+		Text:        strings.Join(slices.Compact(prompts), " / "),
 		ReplyMarkup: replyKeyboard,
 	})
 }
 
+// isLanguageSelection matches the buttons of the HandleLanguage keyboard.
+func (b *Bot) isLanguageSelection(update *models.Update) bool {
+	if update.Message == nil {
+		return false
+	}
+	_, ok := b.catalog.ByName(update.Message.Text)
+	return ok
+}
+
 func (b *Bot) HandleLanguageSelection(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
 	}
 
 	userID := update.Message.From.ID
-	var lang string
-	switch update.Message.Text {
-	case "English":
-		lang = "en"
-	case "Русский":
-		lang = "ru"
-	default:
+	lang, ok := b.catalog.ByName(update.Message.Text)
+	if !ok {
 		return
 	}
 
-	if err := b.repository.SetUserLang(ctx, userID, lang); err == nil {
-		msg := map[string]string{
-			"en": "Language set to English.",
-			"ru": "Язык установлен на русский.",
-		}
+	if err := b.repository.SetUserLang(ctx, userID, lang.Code); err == nil {
 		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   msg[lang],
+			Text:   b.t(lang.Code, "language.selected"),
 		})
 	}
 }
@@ -415,29 +579,20 @@ func (b *Bot) HandleAddQuestion(ctx context.Context, tbot *tgbot.Bot, update *mo
 	fmt.Printf("HandleAddQuestion received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
 	userID := update.CallbackQuery.From.ID
-	if !adminIDs[userID] {
-		return
-	}
 
 	data := update.CallbackQuery.Data
 	parentID, _ := strconv.Atoi(strings.TrimPrefix(data, "add_question_"))
 
-	lang, err := b.repository.GetUserLang(ctx, userID)
-	if err != nil || lang == "" {
-		lang = "en"
+	lang := b.questionLang(ctx, userID, parentID)
+
+	if !b.auth.CanAdd(ctx, userID, lang, parentID) {
+		return
 	}
 
-	b.pendingMutex.Lock()
-	b.pendingQuestionEdits[userID] = &PendingQuestionData{
+	b.startWizard(ctx, tbot, update.CallbackQuery.Message.Message.Chat.ID, userID, &PendingQuestionData{
 		ParentID: parentID,
 		Lang:     lang,
 		EditID:   nil,
-	}
-	b.pendingMutex.Unlock()
-
-	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
-		Text:   fmt.Sprintf("Send your new question for language [%s] and parent [%d] in the format:\n\nquestion|answer", lang, parentID),
 	})
 }
 
@@ -449,9 +604,6 @@ func (b *Bot) HandleEditQuestion(ctx context.Context, tbot *tgbot.Bot, update *m
 	fmt.Printf("HandleEditQuestion received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
 	userID := update.CallbackQuery.From.ID
-	if !adminIDs[userID] {
-		return
-	}
 
 	data := update.CallbackQuery.Data
 	id, err := strconv.Atoi(strings.TrimPrefix(data, "edit_"))
@@ -459,18 +611,30 @@ func (b *Bot) HandleEditQuestion(ctx context.Context, tbot *tgbot.Bot, update *m
 		return
 	}
 
-	b.pendingMutex.Lock()
-	b.pendingQuestionEdits[userID] = &PendingQuestionData{
-		EditID: &id,
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
 	}
-	b.pendingMutex.Unlock()
 
-	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
-		Text:   fmt.Sprintf("Send edited text for question #%d in format:\n\nquestion|answer", id),
-	})
+	b.startWizard(ctx, tbot, update.CallbackQuery.Message.Message.Chat.ID, userID, editSession(q))
+}
+
+// editSession starts editing q from its current content and version.
+func editSession(q *Question) *PendingQuestionData {
+	return &PendingQuestionData{
+		ParentID: q.ParentID,
+		Lang:     q.Lang,
+		EditID:   &q.ID,
+		Version:  q.Version,
+		Text:     q.Text,
+		Answer:   q.Answer,
+		FileType: q.FileType,
+		FileID:   q.FileID,
+	}
 }
 
+// HandleDeleteQuestion asks the admin to confirm a delete, listing the
+// sub-questions that will be removed with it.
 func (b *Bot) HandleDeleteQuestion(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
 	if update.CallbackQuery == nil {
 		return
@@ -479,9 +643,6 @@ func (b *Bot) HandleDeleteQuestion(ctx context.Context, tbot *tgbot.Bot, update
 	fmt.Printf("HandleDeleteQuestion received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
 
 	userID := update.CallbackQuery.From.ID
-	if !adminIDs[userID] {
-		return
-	}
 
 	data := update.CallbackQuery.Data
 	id, err := strconv.Atoi(strings.TrimPrefix(data, "del_"))
@@ -489,22 +650,39 @@ func (b *Bot) HandleDeleteQuestion(ctx context.Context, tbot *tgbot.Bot, update
 		return
 	}
 
-	err = b.repository.DeleteQuestionByID(ctx, id)
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionDelete, q) {
+		return
+	}
+
+	descendants, err := b.repository.GetDescendants(ctx, id)
 	if err != nil {
-		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-			ChatID: update.CallbackQuery.Message.Message.Chat.ID,
-			Text:   "Failed to delete question.",
-		})
+		log.Printf("Failed to fetch descendants of question ID %d: %v", id, err)
 		return
 	}
 
+	lang := b.userLang(ctx, userID)
 	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
 		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
-		Text:   fmt.Sprintf("Question #%d deleted.", id),
+		Text:   b.describeDeletion(lang, q, descendants),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{
+						Text:         b.t(lang, "delete.confirm"),
+						CallbackData: stampCallback(fmt.Sprintf("delok_%d", id)),
+					},
+					{
+						Text:         b.t(lang, "common.cancel"),
+						CallbackData: fmt.Sprintf("delno_%d", id),
+					},
+				},
+			},
+		},
 	})
 }
 
-// HandleMessageInput processes text input for adding or editing questions.
+// HandleMessageInput feeds text and attachments into the admin's add/edit wizard.
 func (b *Bot) HandleMessageInput(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
@@ -521,100 +699,82 @@ func (b *Bot) HandleMessageInput(ctx context.Context, tbot *tgbot.Bot, update *m
 
 	userID := update.Message.From.ID
 
-	b.pendingMutex.RLock()
-	session, ok := b.pendingQuestionEdits[userID]
-	b.pendingMutex.RUnlock()
-
-	msgText := update.Message.Text
-	if update.Message.Caption != "" {
-		msgText = update.Message.Caption
+	session, err := b.loadSession(ctx, tbot, update.Message.Chat.ID, userID)
+	if err != nil {
+		return
 	}
+	if session == nil {
+		// Outside the wizard, question files sent by editors are imports
+		if doc := update.Message.Document; doc != nil && IsImportFile(doc.FileName) {
+			if b.auth.Permissions(ctx, userID).CanEdit() {
+				b.handleImportUpload(ctx, tbot, update.Message)
+			}
+			return
+		}
 
-	if !ok || msgText == "" {
+		// Free text from users who cannot edit is a search query
+		text := strings.TrimSpace(update.Message.Text)
+		if text != "" && !strings.HasPrefix(text, "/") && !b.auth.Permissions(ctx, userID).CanEdit() {
+			b.search(ctx, tbot, update.Message.Chat.ID, userID, text)
+		}
 		return
 	}
 
-	fmt.Printf("message %s\n", msgText)
+	b.handleWizardInput(ctx, tbot, update.Message, session)
+}
 
-	parts := strings.SplitN(msgText, "|", 2)
-	if len(parts) != 2 {
+// loadSession returns the user's pending session, or nil if there is none. A
+// stale session is discarded with a reminder to the admin.
+func (b *Bot) loadSession(ctx context.Context, tbot *tgbot.Bot, chatID, userID int64) (*PendingQuestionData, error) {
+	session, err := b.sessions.Get(ctx, userID)
+	if errors.Is(err, ErrSessionExpired) {
 		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "Invalid format. Use:\n\nquestion|answer",
+			ChatID: chatID,
+			Text:   b.describeExpiredSession(b.userLang(ctx, userID), session),
 		})
-		return
+		return nil, err
 	}
-
-	var fileID, fileType string
-	if update.Message.Document != nil {
-		fileType = fileTypeDoc
-		fileID = update.Message.Document.FileID
-	} else if len(update.Message.Photo) != 0 {
-		fileType = fileTypePhoto
-		fileID = update.Message.Photo[0].FileID
+	if err != nil {
+		log.Println("failed to load session: ", err)
+		return nil, err
 	}
+	return session, nil
+}
 
-	log.Println("FileType: ", fileType)
-	log.Println("FileID: ", fileID)
-
-	questionText := strings.TrimSpace(parts[0])
-	answerText := strings.TrimSpace(parts[1])
+// HandleCancel discards the admin's pending add/edit session.
+func (b *Bot) HandleCancel(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
 
-	if session.EditID != nil {
-		// Update existing question
-		err := b.repository.UpdateQuestion(ctx, *session.EditID, questionText, answerText)
-		if err != nil {
-			tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "Failed to update question.",
-			})
-			return
-		}
+	userID := update.Message.From.ID
 
-		err = b.repository.UpdateQuestionFile(ctx, *session.EditID, fileType, fileID)
-		if err != nil {
-			tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "Failed to update question.",
-			})
-			return
-		}
+	session, err := b.sessions.Get(ctx, userID)
+	if err != nil && !errors.Is(err, ErrSessionExpired) {
+		log.Println("failed to load session: ", err)
+		return
+	}
 
-		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "Question updated successfully.",
-		})
-	} else {
-		// Create new question
-		qID, err := b.repository.CreateQuestion(ctx, session.Lang, questionText, answerText, session.ParentID)
-		if err != nil {
-			tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "Failed to create question.",
-			})
-			log.Println("failed to create question: ", err)
+	lang := b.userLang(ctx, userID)
+	text := b.t(lang, "common.nothing_to_cancel")
+	if session != nil {
+		if err := b.sessions.Delete(ctx, userID); err != nil {
+			log.Println("failed to clear session: ", err)
 			return
 		}
-		log.Println("question: ", qID)
+		text = b.t(lang, "common.cancelled")
+	}
 
-		err = b.repository.UpdateQuestionFile(ctx, qID, fileType, fileID)
-		if err != nil {
-			tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "Failed to create question.",
-			})
-			log.Println("failed to create question: ", err)
-			return
-		}
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
 
-		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "Question created successfully.",
-		})
+// describeExpiredSession builds the reminder sent when a stale session is discarded.
+func (b *Bot) describeExpiredSession(lang string, session *PendingQuestionData) string {
+	if session.EditID != nil {
+		return b.t(lang, "session.expired_edit", *session.EditID)
 	}
-
-	// Clear session
-	b.pendingMutex.Lock()
-	delete(b.pendingQuestionEdits, userID)
-	b.pendingMutex.Unlock()
+	return b.t(lang, "session.expired_add", session.ParentID)
 }
@@ -0,0 +1,388 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// RevisionAction tells what produced a revision.
+type RevisionAction string
+
+const (
+	// RevisionInitial snapshots a question that existed before revisions were recorded.
+	RevisionInitial RevisionAction = "initial"
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionFile    RevisionAction = "file"
+	RevisionRestore RevisionAction = "restore"
+)
+
+const (
+	// maxListedRevisions limits the revisions offered by the History button.
+	maxListedRevisions = 10
+
+	// maxMessageLength keeps messages below Telegram's 4096 character limit.
+	maxMessageLength = 4000
+)
+
+// Revision is a snapshot of a question taken after a change.
+type Revision struct {
+	ID         int            `json:"id"`
+	QuestionID int            `json:"question_id"`
+	Action     RevisionAction `json:"action"`
+	Text       string         `json:"text"`
+	Answer     string         `json:"answer"`
+	FileType   string         `json:"file_type"`
+	FileID     string         `json:"file_id"`
+	AuthorID   int64          `json:"author_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// revisionTitle names a revision in lists and headings.
+func (b *Bot) revisionTitle(lang string, r Revision) string {
+	author := b.t(lang, "history.unknown_author")
+	if r.AuthorID != 0 {
+		author = strconv.FormatInt(r.AuthorID, 10)
+	}
+	return b.t(lang, "history.revision", r.ID, r.CreatedAt.Format("2006-01-02 15:04"), b.t(lang, "history.actions."+string(r.Action)), author)
+}
+
+// recordRevision snapshots the question as currently stored.
+func recordRevision(ctx context.Context, repo BotRepository, questionID int, action RevisionAction, authorID int64) error {
+	q, err := repo.GetQuestionShallow(ctx, questionID)
+	if err != nil {
+		return fmt.Errorf("fetch question ID %d for revision: %w", questionID, err)
+	}
+
+	_, err = repo.AddRevision(ctx, Revision{
+		QuestionID: q.ID,
+		Action:     action,
+		Text:       q.Text,
+		Answer:     q.Answer,
+		FileType:   q.FileType,
+		FileID:     q.FileID,
+		AuthorID:   authorID,
+	})
+	if err != nil {
+		return fmt.Errorf("record revision of question ID %d: %w", questionID, err)
+	}
+	return nil
+}
+
+// ensureBaselineRevision records the current state of a question that has no
+// history yet, so the first edit can be diffed and rolled back.
+func ensureBaselineRevision(ctx context.Context, repo BotRepository, questionID int) error {
+	revisions, err := repo.ListRevisions(ctx, questionID)
+	if err != nil {
+		return fmt.Errorf("list revisions of question ID %d: %w", questionID, err)
+	}
+	if len(revisions) == 0 {
+		return recordRevision(ctx, repo, questionID, RevisionInitial, 0)
+	}
+	return nil
+}
+
+// HandleHistory lists the latest revisions of a question.
+func (b *Bot) HandleHistory(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleHistory received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "hist_"))
+	if err != nil {
+		return
+	}
+
+	if !b.auth.Permissions(ctx, update.CallbackQuery.From.ID).CanView() {
+		return
+	}
+	if _, err := b.repository.GetQuestionShallow(ctx, id); err != nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	lang := b.userLang(ctx, update.CallbackQuery.From.ID)
+
+	revisions, err := b.repository.ListRevisions(ctx, id)
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "history.failed")})
+		return
+	}
+	if len(revisions) == 0 {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "history.empty", id)})
+		return
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for i, rev := range revisions {
+		if i == maxListedRevisions {
+			break
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: b.revisionTitle(lang, rev), CallbackData: fmt.Sprintf("rev_%d", rev.ID)},
+		})
+	}
+
+	text := b.t(lang, "history.title", id, len(revisions))
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+}
+
+// HandleRevision shows one revision with diff and restore buttons.
+func (b *Bot) HandleRevision(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleRevision received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "rev_"))
+	if err != nil {
+		return
+	}
+
+	userID := update.CallbackQuery.From.ID
+	rev, q, revisions, ok := b.loadRevision(ctx, userID, id)
+	if !ok {
+		return
+	}
+
+	// revisions are newest first
+	var newer, older *Revision
+	for i := range revisions {
+		if revisions[i].ID != rev.ID {
+			continue
+		}
+		if i > 0 {
+			newer = &revisions[0]
+		}
+		if i+1 < len(revisions) {
+			older = &revisions[i+1]
+		}
+		break
+	}
+
+	lang := b.userLang(ctx, userID)
+
+	var row []models.InlineKeyboardButton
+	if older != nil {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "history.diff_previous"), CallbackData: fmt.Sprintf("rdiff_%d_%d", older.ID, rev.ID)})
+	}
+	if newer != nil {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "history.diff_current"), CallbackData: fmt.Sprintf("rdiff_%d_%d", rev.ID, newer.ID)})
+		if b.auth.Can(ctx, userID, ActionEdit, q) {
+			row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "history.restore"), CallbackData: fmt.Sprintf("rrest_%d", rev.ID)})
+		}
+	}
+
+	text := b.t(lang, "history.show", b.revisionTitle(lang, *rev), rev.QuestionID) + "\n\n" +
+		b.describeContent(lang, rev.Text, rev.Answer, rev.FileType)
+
+	params := &tgbot.SendMessageParams{
+		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
+		Text:   truncateText(text, maxMessageLength),
+	}
+	if len(row) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}}
+	}
+	tbot.SendMessage(ctx, params)
+}
+
+// HandleRevisionDiff shows the changes between two revisions.
+func (b *Bot) HandleRevisionDiff(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleRevisionDiff received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	parts := strings.Split(update.CallbackQuery.Data, "_")
+	if len(parts) != 3 {
+		return
+	}
+	fromID, err1 := strconv.Atoi(parts[1])
+	toID, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
+		Text:   b.revisionDiff(ctx, b.userLang(ctx, update.CallbackQuery.From.ID), update.CallbackQuery.From.ID, fromID, toID),
+	})
+}
+
+// HandleDiffCommand implements /diff <rev> <rev> for any two revisions of a question.
+func (b *Bot) HandleDiffCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleDiffCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	lang := b.userLang(ctx, update.Message.From.ID)
+	text := b.t(lang, "history.diff_usage")
+	args := strings.Fields(update.Message.Text)
+	if len(args) == 3 && args[0] == "/diff" {
+		// Accept both "12" and "r12" as shown in the history list
+		fromID, err1 := strconv.Atoi(strings.TrimPrefix(args[1], "r"))
+		toID, err2 := strconv.Atoi(strings.TrimPrefix(args[2], "r"))
+		if err1 == nil && err2 == nil {
+			text = b.revisionDiff(ctx, lang, update.Message.From.ID, fromID, toID)
+		}
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+func (b *Bot) revisionDiff(ctx context.Context, lang string, userID int64, fromID, toID int) string {
+	// Always diff from the older revision to the newer one
+	if fromID > toID {
+		fromID, toID = toID, fromID
+	}
+
+	from, _, _, ok := b.loadRevision(ctx, userID, fromID)
+	if !ok {
+		return b.t(lang, "history.not_found", fromID)
+	}
+	to, _, _, ok := b.loadRevision(ctx, userID, toID)
+	if !ok {
+		return b.t(lang, "history.not_found", toID)
+	}
+	if from.QuestionID != to.QuestionID {
+		return b.t(lang, "history.different_questions")
+	}
+
+	unchanged := b.t(lang, "history.unchanged")
+	field := func(a, b string) string {
+		if a == b {
+			return unchanged
+		}
+		return diffWords(a, b)
+	}
+
+	attachment := unchanged
+	if from.FileType != to.FileType || from.FileID != to.FileID {
+		attachment = fmt.Sprintf("%s → %s", b.describeFile(lang, from.FileType), b.describeFile(lang, to.FileType))
+	}
+
+	text := b.t(lang, "history.diff", from.QuestionID, from.ID, to.ID, field(from.Text, to.Text), field(from.Answer, to.Answer), attachment)
+	return truncateText(text, maxMessageLength)
+}
+
+// loadRevision fetches a revision, its question and all revisions of the
+// question, provided the user may read the history.
+func (b *Bot) loadRevision(ctx context.Context, userID int64, id int) (*Revision, *Question, []Revision, bool) {
+	if !b.auth.Permissions(ctx, userID).CanView() {
+		return nil, nil, nil, false
+	}
+
+	rev, err := b.repository.GetRevision(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrRevisionNotFound) {
+			log.Printf("Failed to fetch revision %d: %v", id, err)
+		}
+		return nil, nil, nil, false
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, rev.QuestionID)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+
+	revisions, err := b.repository.ListRevisions(ctx, rev.QuestionID)
+	if err != nil {
+		log.Printf("Failed to list revisions of question ID %d: %v", rev.QuestionID, err)
+		return nil, nil, nil, false
+	}
+
+	return rev, q, revisions, true
+}
+
+// HandleRevisionRestore rolls a question back to a revision by writing its
+// content as a new revision; history is never rewritten.
+func (b *Bot) HandleRevisionRestore(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleRevisionRestore received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "rrest_"))
+	if err != nil {
+		return
+	}
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	lang := b.userLang(ctx, userID)
+
+	rev, q, _, ok := b.loadRevision(ctx, userID, id)
+	if !ok || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		current, err := tx.GetQuestionShallow(ctx, rev.QuestionID)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateQuestion(ctx, rev.QuestionID, rev.Text, rev.Answer); err != nil {
+			return err
+		}
+		if err := tx.UpdateQuestionFile(ctx, rev.QuestionID, rev.FileType, rev.FileID); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, tx, rev.QuestionID, RevisionRestore, userID); err != nil {
+			return err
+		}
+		restored, err := tx.GetQuestionShallow(ctx, rev.QuestionID)
+		if err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionRevert, QuestionID: rev.QuestionID}, current, restored)
+	})
+	if err != nil {
+		log.Println("failed to restore question: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "history.restore_failed")})
+		return
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   b.t(lang, "history.restored", rev.QuestionID, rev.ID),
+	})
+}
+
+// truncateText shortens s to at most max runes.
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,879 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"qaBot/pkg/i18n"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// maxImportSize limits the size of uploaded import files.
+	maxImportSize = 5 << 20
+
+	// maxImportProblems limits the validation problems reported for one file.
+	maxImportProblems = 20
+)
+
+// importCSVColumns are the columns a CSV import may have. row and parent_row
+// link the rows of the file together; id and parent_id name existing
+// questions, as in YAML and JSON.
+var importCSVColumns = []string{"row", "parent_row", "id", "parent_id", "lang", "text", "answer", "file_type", "file_id"}
+
+// Import is a question tree read from a YAML, JSON or CSV file.
+//
+// In YAML and JSON the questions are nested through sub_questions, as in the
+// Question struct, and sub-questions inherit the language of their parent. A
+// non-zero id names the existing question to update; a top-level parent_id
+// attaches the question under an existing one. Questions without an id are
+// matched by their text among the existing siblings. Existing questions that
+// are not in the file are left alone.
+type Import struct {
+	Source    string
+	Questions []Question
+}
+
+// ImportError lists what is wrong with an import file.
+type ImportError struct {
+	Problems []ImportProblem
+}
+
+// ImportProblem is one thing wrong with an import file. Key names its message
+// below import.problem in the locales, which is formatted with Args.
+type ImportProblem struct {
+	// Line is the CSV line the problem is on, 0 for other formats
+	Line int
+	// Path holds the labels of the question and its ancestors, outermost first
+	Path []string
+	Key  string
+	Args []any
+}
+
+// importProblem returns an ImportError with a single problem.
+func importProblem(key string, args ...any) *ImportError {
+	return &ImportError{Problems: []ImportProblem{{Key: key, Args: args}}}
+}
+
+// Error lists the problems by their keys, for logs. Describe renders them for
+// people.
+func (e *ImportError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.Key
+		if len(p.Args) != 0 {
+			problems[i] += fmt.Sprint(p.Args)
+		}
+		switch {
+		case p.Line != 0:
+			problems[i] = fmt.Sprintf("line %d: %s", p.Line, problems[i])
+		case len(p.Path) != 0:
+			problems[i] = strings.Join(p.Path, " › ") + ": " + problems[i]
+		}
+	}
+	return "invalid import: " + strings.Join(problems, "; ")
+}
+
+// Describe lists the first maxImportProblems problems in lang, one per line.
+func (e *ImportError) Describe(catalog *i18n.Catalog, lang string) string {
+	problems := e.Problems
+	if len(problems) > maxImportProblems {
+		problems = problems[:maxImportProblems]
+	}
+
+	lines := make([]string, 0, len(problems)+1)
+	for _, p := range problems {
+		line := catalog.T(lang, "import.problem."+p.Key, p.Args...)
+		switch {
+		case p.Line != 0:
+			line = catalog.T(lang, "import.at_line", p.Line, line)
+		case len(p.Path) != 0:
+			line = strings.Join(p.Path, " › ") + ": " + line
+		}
+		lines = append(lines, line)
+	}
+	if more := len(e.Problems) - len(problems); more > 0 {
+		lines = append(lines, catalog.T(lang, "import.more_problems", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ImportAction tells what an import does with one question.
+type ImportAction string
+
+const (
+	ImportCreate    ImportAction = "create"
+	ImportUpdate    ImportAction = "update"
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+// ImportStep is the plan for one question of an import. Steps are ordered
+// parents first.
+type ImportStep struct {
+	Action ImportAction
+	// Question holds the imported values; its SubQuestions are not used
+	Question Question
+	// Current is the matched existing question, nil for creates
+	Current *Question
+	// Parent is the index of the step of the parent question, or -1 when the
+	// parent already exists and Question.ParentID holds it
+	Parent int
+	// Path holds the texts of the ancestors, for reports
+	Path []string
+}
+
+// ImportPlan is the dry-run result of an import.
+type ImportPlan struct {
+	Source string
+	Steps  []ImportStep
+}
+
+// importResult is the audit payload of an import.
+type importResult struct {
+	Source  string `json:"source"`
+	Created []int  `json:"created,omitempty"`
+	Updated []int  `json:"updated,omitempty"`
+}
+
+// IsImportFile reports whether a file name has an extension ParseImport reads.
+func IsImportFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml", ".json", ".csv":
+		return true
+	}
+	return false
+}
+
+// ParseImport reads an import file, choosing the format by its extension.
+// The result still has to be validated.
+func ParseImport(name string, data []byte) (*Import, error) {
+	var (
+		questions []Question
+		err       error
+	)
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".yaml", ".yml":
+		var js []byte
+		if js, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, importProblem("parse", err)
+		}
+		questions, err = decodeImportJSON(js)
+	case ".json":
+		questions, err = decodeImportJSON(data)
+	case ".csv":
+		questions, err = parseImportCSV(data)
+	default:
+		return nil, importProblem("format", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, importProblem("empty")
+	}
+	return &Import{Source: path.Base(name), Questions: questions}, nil
+}
+
+// decodeImportJSON decodes a list of nested questions, rejecting unknown
+// fields so that misspelt keys are not silently dropped.
+func decodeImportJSON(data []byte) ([]Question, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var questions []Question
+	if err := dec.Decode(&questions); err != nil {
+		return nil, importProblem("parse", err)
+	}
+	return questions, nil
+}
+
+// parseImportCSV reads one question per row, after a header naming the
+// columns. Rows are nested by parent_row, which refers to the row column of
+// another row; rows with an empty parent_row are top-level questions.
+func parseImportCSV(data []byte) ([]Question, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, importProblem("parse", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importCSVColumns, name) {
+			return nil, importProblem("csv_column_unknown", name, strings.Join(importCSVColumns, ", "))
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"text", "answer"} {
+		if _, ok := columns[required]; !ok {
+			return nil, importProblem("csv_column_missing", required)
+		}
+	}
+
+	type row struct {
+		line      int
+		label     string
+		parentRow string
+		question  Question
+	}
+
+	var (
+		rows     []row
+		problems []ImportProblem
+	)
+	byLabel := make(map[string]int) // row column -> row index
+	for i, record := range records[1:] {
+		line := i + 2
+		field := func(name string) string {
+			if c, ok := columns[name]; ok {
+				return strings.TrimSpace(record[c])
+			}
+			return ""
+		}
+		number := func(name string) int {
+			s := field(name)
+			if s == "" {
+				return 0
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				problems = append(problems, ImportProblem{Line: line, Key: "csv_number", Args: []any{name, s}})
+			}
+			return n
+		}
+
+		rw := row{
+			line:      line,
+			label:     field("row"),
+			parentRow: field("parent_row"),
+			question: Question{
+				ID:       number("id"),
+				ParentID: number("parent_id"),
+				Lang:     field("lang"),
+				Text:     field("text"),
+				Answer:   field("answer"),
+				FileType: field("file_type"),
+				FileID:   field("file_id"),
+			},
+		}
+		if rw.label != "" {
+			if _, ok := byLabel[rw.label]; ok {
+				problems = append(problems, ImportProblem{Line: line, Key: "csv_row_duplicate", Args: []any{rw.label}})
+			}
+			byLabel[rw.label] = len(rows)
+		}
+		rows = append(rows, rw)
+	}
+
+	children := make(map[int][]int) // row index -> child row indexes
+	var roots []int
+	for i, rw := range rows {
+		if rw.parentRow == "" {
+			roots = append(roots, i)
+			continue
+		}
+		parent, ok := byLabel[rw.parentRow]
+		if !ok {
+			problems = append(problems, ImportProblem{Line: rw.line, Key: "csv_parent_unknown", Args: []any{rw.parentRow}})
+			continue
+		}
+		children[parent] = append(children[parent], i)
+	}
+	if len(problems) != 0 {
+		return nil, &ImportError{Problems: problems}
+	}
+
+	nested := make([]bool, len(rows))
+	var build func(i int) Question
+	build = func(i int) Question {
+		nested[i] = true
+		q := rows[i].question
+		for _, c := range children[i] {
+			q.SubQuestions = append(q.SubQuestions, build(c))
+		}
+		return q
+	}
+
+	questions := make([]Question, 0, len(roots))
+	for _, i := range roots {
+		questions = append(questions, build(i))
+	}
+
+	// Rows that cannot be reached from a top-level row are parents of one another
+	for i, rw := range rows {
+		if !nested[i] {
+			problems = append(problems, ImportProblem{Line: rw.line, Key: "csv_parent_cycle", Args: []any{rw.parentRow}})
+		}
+	}
+	if len(problems) != 0 {
+		return nil, &ImportError{Problems: problems}
+	}
+	return questions, nil
+}
+
+// Validate checks the questions and fills in inherited languages. hasLang
+// reports whether a language is configured. The error is an *ImportError.
+func (imp *Import) Validate(hasLang func(lang string) bool) error {
+	var problems []ImportProblem
+	ids := make(map[int]bool)
+
+	var walk func(questions []Question, parent *Question, path []string)
+	walk = func(questions []Question, parent *Question, path []string) {
+		siblings := make(map[string]bool)
+		for i := range questions {
+			q := &questions[i]
+			q.Text = strings.TrimSpace(q.Text)
+			q.Answer = strings.TrimSpace(q.Answer)
+
+			// Questions without text are labelled by their position
+			label := fmt.Sprintf("(%d)", i+1)
+			if q.Text != "" {
+				label = strconv.Quote(truncateText(q.Text, 40))
+			}
+			where := append(path[:len(path):len(path)], label)
+			report := func(key string, args ...any) {
+				problems = append(problems, ImportProblem{Path: where, Key: key, Args: args})
+			}
+
+			if parent != nil {
+				if q.Lang == "" {
+					q.Lang = parent.Lang
+				} else if parent.Lang != "" && q.Lang != parent.Lang {
+					report("lang_differs", q.Lang, parent.Lang)
+				}
+				if q.ParentID != 0 && q.ParentID != parent.ID {
+					report("parent_mismatch", q.ParentID)
+				}
+			}
+			switch {
+			case q.Lang == "":
+				report("lang_missing")
+			case !hasLang(q.Lang):
+				report("lang_unknown", q.Lang)
+			}
+
+			if q.Text == "" {
+				report("text_missing")
+			}
+			if q.Answer == "" {
+				report("answer_missing")
+			}
+			switch q.FileType {
+			case "":
+				if q.FileID != "" {
+					report("file_type_missing")
+				}
+			case fileTypeDoc, fileTypePhoto:
+				if q.FileID == "" {
+					report("file_id_missing")
+				}
+			default:
+				report("file_type_unknown", q.FileType, fileTypeDoc, fileTypePhoto)
+			}
+
+			if q.ID != 0 {
+				if ids[q.ID] {
+					report("id_duplicate", q.ID)
+				}
+				ids[q.ID] = true
+			}
+			key := fmt.Sprintf("%d\x00%s\x00%s", q.ParentID, q.Lang, q.Text)
+			if q.Text != "" && siblings[key] {
+				report("sibling_duplicate")
+			}
+			siblings[key] = true
+
+			walk(q.SubQuestions, q, where)
+		}
+	}
+	walk(imp.Questions, nil, nil)
+
+	if len(problems) != 0 {
+		return &ImportError{Problems: problems}
+	}
+	return nil
+}
+
+// Plan matches the validated questions against the database and works out
+// what applying the import would change, without changing anything.
+func (imp *Import) Plan(ctx context.Context, repo BotRepository) (*ImportPlan, error) {
+	plan := &ImportPlan{Source: imp.Source}
+	var problems []ImportProblem
+	claimed := make(map[int]bool)
+
+	// existing returns the current children of an existing parent, or the
+	// top-level questions of lang when parentID is 0
+	existing := func(parentID int, lang string) ([]Question, error) {
+		if parentID == 0 {
+			return repo.GetQuestionsByLang(ctx, lang)
+		}
+		parent, err := repo.GetQuestionByID(ctx, parentID)
+		if isNotFound(err) {
+			problems = append(problems, ImportProblem{Key: "parent_not_found", Args: []any{parentID}})
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.Lang != lang {
+			problems = append(problems, ImportProblem{Key: "parent_lang", Args: []any{parentID, parent.Lang, lang}})
+		}
+		return parent.SubQuestions, nil
+	}
+
+	// walk plans questions whose parent is the step at index parent, or the
+	// existing question parentID when parent is -1. candidates are the
+	// existing children of that parent.
+	var walk func(questions []Question, parent, parentID int, candidates []Question, path []string) error
+	walk = func(questions []Question, parent, parentID int, candidates []Question, path []string) error {
+		for _, q := range questions {
+			current, err := matchImported(ctx, repo, q, candidates, claimed)
+			var importErr *ImportError
+			if errors.As(err, &importErr) {
+				for _, p := range importErr.Problems {
+					p.Path = append(path[:len(path):len(path)], truncateText(q.Text, 40))
+					problems = append(problems, p)
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			step := ImportStep{Action: ImportCreate, Question: q, Parent: parent, Path: path}
+			step.Question.ID = 0
+			step.Question.ParentID = parentID
+			step.Question.SubQuestions = nil
+			if current != nil {
+				claimed[current.ID] = true
+				step.Current = current
+				step.Question.ID = current.ID
+				step.Action = ImportUnchanged
+				if len(importChanges(current, &q)) != 0 {
+					step.Action = ImportUpdate
+				}
+			}
+
+			index := len(plan.Steps)
+			plan.Steps = append(plan.Steps, step)
+
+			// The children of a new question are all new as well
+			childPath := append(path[:len(path):len(path)], truncateText(q.Text, 30))
+			if current != nil {
+				err = walk(q.SubQuestions, -1, current.ID, current.SubQuestions, childPath)
+			} else {
+				err = walk(q.SubQuestions, index, 0, nil, childPath)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	loaded := make(map[string][]Question) // parent ID and language -> existing children
+	for _, q := range imp.Questions {
+		key := fmt.Sprintf("%d/%s", q.ParentID, q.Lang)
+		candidates, ok := loaded[key]
+		if !ok {
+			var err error
+			if candidates, err = existing(q.ParentID, q.Lang); err != nil {
+				return nil, err
+			}
+			loaded[key] = candidates
+		}
+		if err := walk([]Question{q}, -1, q.ParentID, candidates, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(problems) != 0 {
+		return nil, &ImportError{Problems: problems}
+	}
+	return plan, nil
+}
+
+// matchImported finds the existing question an imported one stands for among
+// the candidates: by id when it has one, else by text. It returns nil for new
+// questions, including those whose id no longer exists, and an *ImportError
+// for ids of questions under another parent.
+func matchImported(ctx context.Context, repo BotRepository, q Question, candidates []Question, claimed map[int]bool) (*Question, error) {
+	if q.ID != 0 {
+		for i := range candidates {
+			if candidates[i].ID == q.ID {
+				return &candidates[i], nil
+			}
+		}
+		// Questions are not moved by imports
+		current, err := repo.GetQuestionShallow(ctx, q.ID)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, importProblem("moved", current.ID)
+	}
+
+	for i := range candidates {
+		if !claimed[candidates[i].ID] && strings.TrimSpace(candidates[i].Text) == q.Text {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// isNotFound reports whether err is the no rows error of either backend.
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows)
+}
+
+// importChanges names the fields an import changes in a question.
+func importChanges(current, imported *Question) []string {
+	var changes []string
+	if current.Text != imported.Text {
+		changes = append(changes, "text")
+	}
+	if current.Answer != imported.Answer {
+		changes = append(changes, "answer")
+	}
+	if current.FileType != imported.FileType || current.FileID != imported.FileID {
+		changes = append(changes, "attachment")
+	}
+	return changes
+}
+
+// Count returns the number of steps with the given action.
+func (p *ImportPlan) Count(action ImportAction) int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Describe lists the planned changes as a diff in lang: "+" for new
+// questions, "~" for updated ones with the changed fields.
+func (p *ImportPlan) Describe(catalog *i18n.Catalog, lang string) string {
+	var sb strings.Builder
+	sb.WriteString(catalog.T(lang, "import.plan", p.Source, p.Count(ImportCreate), p.Count(ImportUpdate), p.Count(ImportUnchanged)))
+
+	for _, s := range p.Steps {
+		title := strings.Join(append(s.Path[:len(s.Path):len(s.Path)], truncateText(s.Question.Text, 60)), " › ")
+		switch s.Action {
+		case ImportCreate:
+			fmt.Fprintf(&sb, "\n+ [%s] %s", s.Question.Lang, title)
+		case ImportUpdate:
+			changes := importChanges(s.Current, &s.Question)
+			for i, field := range changes {
+				changes[i] = catalog.T(lang, "import.fields."+field)
+			}
+			fmt.Fprintf(&sb, "\n~ #%d [%s] %s: %s", s.Current.ID, s.Question.Lang, title, strings.Join(changes, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// Apply plans the import and carries it out in one transaction, recording
+// revisions and an audit entry on behalf of actorID. allow, when not nil, may
+// reject the plan before anything is written; it runs inside the transaction.
+func (imp *Import) Apply(ctx context.Context, repo BotRepository, actorID int64, allow func(tx BotRepository, plan *ImportPlan) error) (*ImportPlan, error) {
+	var plan *ImportPlan
+	err := repo.WithTx(ctx, func(tx BotRepository) error {
+		var err error
+		if plan, err = imp.Plan(ctx, tx); err != nil {
+			return err
+		}
+		if allow != nil {
+			if err := allow(tx, plan); err != nil {
+				return err
+			}
+		}
+		return plan.apply(ctx, tx, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (p *ImportPlan) apply(ctx context.Context, tx BotRepository, actorID int64) error {
+	result := importResult{Source: p.Source}
+	ids := make([]int, len(p.Steps)) // step index -> question ID
+
+	for i, s := range p.Steps {
+		q := s.Question
+		switch s.Action {
+		case ImportCreate:
+			parentID := q.ParentID
+			if s.Parent >= 0 {
+				parentID = ids[s.Parent]
+			}
+			id, err := tx.CreateQuestion(ctx, q.Lang, q.Text, q.Answer, q.FileType, q.FileID, parentID)
+			if err != nil {
+				return err
+			}
+			if err := recordRevision(ctx, tx, id, RevisionCreate, actorID); err != nil {
+				return err
+			}
+			ids[i] = id
+			result.Created = append(result.Created, id)
+		case ImportUpdate:
+			id := s.Current.ID
+			if err := ensureBaselineRevision(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := tx.UpdateQuestion(ctx, id, q.Text, q.Answer); err != nil {
+				return err
+			}
+			if err := tx.UpdateQuestionFile(ctx, id, q.FileType, q.FileID); err != nil {
+				return err
+			}
+			if err := recordRevision(ctx, tx, id, RevisionUpdate, actorID); err != nil {
+				return err
+			}
+			ids[i] = id
+			result.Updated = append(result.Updated, id)
+		default:
+			ids[i] = s.Current.ID
+		}
+	}
+
+	if len(result.Created) == 0 && len(result.Updated) == 0 {
+		return nil
+	}
+	return addAuditEntry(ctx, tx, AuditEntry{ActorID: actorID, Action: AuditQuestionImport}, nil, result)
+}
+
+// checkImportPermissions makes sure the user may make every change of the
+// plan. New questions under new parents are covered by their ancestors.
+func checkImportPermissions(ctx context.Context, auth *AuthService, userID int64, plan *ImportPlan) error {
+	for _, s := range plan.Steps {
+		switch {
+		case s.Action == ImportCreate && s.Parent < 0:
+			if !auth.CanAdd(ctx, userID, s.Question.Lang, s.Question.ParentID) {
+				if s.Question.ParentID == 0 {
+					return importProblem("add_top", s.Question.Lang)
+				}
+				return importProblem("add", s.Question.Lang, s.Question.ParentID)
+			}
+		case s.Action == ImportUpdate:
+			if !auth.Can(ctx, userID, ActionEdit, s.Current) {
+				return importProblem("edit", s.Current.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// handleImportUpload shows what importing an uploaded file would change, with
+// buttons to apply it. The preview replies to the document, which the Apply
+// button reads again.
+func (b *Bot) handleImportUpload(ctx context.Context, tbot *tgbot.Bot, msg *models.Message) {
+	chatID := msg.Chat.ID
+	lang := b.userLang(ctx, msg.From.ID)
+
+	imp, err := b.readImport(ctx, tbot, msg.Document)
+	if err == nil {
+		var plan *ImportPlan
+		if plan, err = imp.Plan(ctx, b.repository); err == nil {
+			if err = checkImportPermissions(ctx, b.auth, msg.From.ID, plan); err == nil {
+				b.sendImportPreview(ctx, tbot, lang, msg, plan)
+				return
+			}
+		}
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID:          chatID,
+		Text:            truncateText(b.describeImportError(lang, msg.Document.FileName, err), maxMessageLength),
+		ReplyParameters: &models.ReplyParameters{MessageID: msg.ID},
+	})
+}
+
+func (b *Bot) sendImportPreview(ctx context.Context, tbot *tgbot.Bot, lang string, msg *models.Message, plan *ImportPlan) {
+	params := &tgbot.SendMessageParams{
+		ChatID:          msg.Chat.ID,
+		Text:            truncateText(plan.Describe(b.catalog, lang), maxMessageLength),
+		ReplyParameters: &models.ReplyParameters{MessageID: msg.ID},
+	}
+	if plan.Count(ImportCreate)+plan.Count(ImportUpdate) == 0 {
+		params.Text += "\n\n" + b.t(lang, "import.nothing")
+	} else {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{Text: b.t(lang, "import.apply"), CallbackData: stampCallback("impok")},
+					{Text: b.t(lang, "common.cancel"), CallbackData: "impno"},
+				},
+			},
+		}
+	}
+	tbot.SendMessage(ctx, params)
+}
+
+// HandleImportConfirm applies the import previewed in the message, reading the
+// document it replies to again.
+func (b *Bot) HandleImportConfirm(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleImportConfirm received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	userID := update.CallbackQuery.From.ID
+	preview := update.CallbackQuery.Message.Message
+	chatID := preview.Chat.ID
+	lang := b.userLang(ctx, userID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	_, expired, ok := parseStampedCallback(update.CallbackQuery.Data)
+	if !ok {
+		return
+	}
+
+	// Remove the Apply/Cancel buttons either way
+	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
+		ChatID:    chatID,
+		MessageID: preview.ID,
+	})
+
+	if expired {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "import.expired"),
+		})
+		return
+	}
+	if preview.ReplyToMessage == nil || preview.ReplyToMessage.Document == nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "import.file_gone"),
+		})
+		return
+	}
+	doc := preview.ReplyToMessage.Document
+
+	if !b.auth.Permissions(ctx, userID).CanEdit() {
+		return
+	}
+
+	imp, err := b.readImport(ctx, tbot, doc)
+	var plan *ImportPlan
+	if err == nil {
+		plan, err = imp.Apply(ctx, b.repository, userID, func(tx BotRepository, plan *ImportPlan) error {
+			return checkImportPermissions(ctx, NewAuthService(tx), userID, plan)
+		})
+	}
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   truncateText(b.describeImportError(lang, doc.FileName, err), maxMessageLength),
+		})
+		return
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   b.t(lang, "import.done", plan.Source, plan.Count(ImportCreate), plan.Count(ImportUpdate), plan.Count(ImportUnchanged)),
+	})
+}
+
+// HandleImportCancel dismisses an import preview.
+func (b *Bot) HandleImportCancel(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            b.t(b.userLang(ctx, update.CallbackQuery.From.ID), "import.cancelled"),
+	})
+
+	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
+		ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+}
+
+// readImport downloads, parses and validates an uploaded import file.
+func (b *Bot) readImport(ctx context.Context, tbot *tgbot.Bot, doc *models.Document) (*Import, error) {
+	if doc.FileSize > maxImportSize {
+		return nil, importProblem("too_large", maxImportSize>>20)
+	}
+	data, err := downloadFile(ctx, tbot, doc.FileID, maxImportSize)
+	if err != nil {
+		log.Println("failed to download import file: ", err)
+		return nil, importProblem("download")
+	}
+
+	imp, err := ParseImport(doc.FileName, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := imp.Validate(b.catalog.Has); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// downloadFile fetches a file sent to the bot, refusing files over limit bytes.
+func downloadFile(ctx context.Context, tbot *tgbot.Bot, fileID string, limit int64) ([]byte, error) {
+	file, err := tbot.GetFile(ctx, &tgbot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tbot.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file is larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func (b *Bot) describeImportError(lang, name string, err error) string {
+	var importErr *ImportError
+	if errors.As(err, &importErr) {
+		return b.t(lang, "import.problems", name, importErr.Describe(b.catalog, lang))
+	}
+	return b.t(lang, "import.failed", name, err)
+}
@@ -0,0 +1,357 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"qaBot/locales"
+	"qaBot/pkg/i18n"
+)
+
+func hasTestLang(lang string) bool {
+	return lang == "en" || lang == "ru"
+}
+
+// problemKeys lists the problems of an *ImportError as "line: key" or
+// "path: key", or the error itself when it is of another type.
+func problemKeys(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		return []string{err.Error()}
+	}
+	keys := make([]string, len(importErr.Problems))
+	for i, p := range importErr.Problems {
+		switch {
+		case p.Line != 0:
+			keys[i] = fmt.Sprintf("%d: %s", p.Line, p.Key)
+		case len(p.Path) != 0:
+			keys[i] = strings.Join(p.Path, " › ") + ": " + p.Key
+		default:
+			keys[i] = p.Key
+		}
+	}
+	return keys
+}
+
+func TestParseImportCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []string
+	}{
+		{"nested rows", "row,parent_row,lang,text,answer\n1,,en,Root,A\n2,1,,Child,B\n3,2,,Grandchild,C\n", nil},
+		{"rows without a row column", "lang,text,answer\nen,One,A\nen,Two,B\n", nil},
+		{"row that is its own parent", "row,parent_row,text,answer\n1,,Root,A\n2,2,Self,B\n", []string{"3: csv_parent_cycle"}},
+		{"rows that are parents of one another", "row,parent_row,text,answer\n1,,Root,A\n2,3,X,B\n3,2,Y,C\n", []string{"3: csv_parent_cycle", "4: csv_parent_cycle"}},
+		{"duplicate row", "row,text,answer\n1,X,A\n1,Y,B\n", []string{"3: csv_row_duplicate"}},
+		{"unknown parent row", "row,parent_row,text,answer\n1,,X,A\n2,7,Y,B\n", []string{"3: csv_parent_unknown"}},
+		{"invalid id", "id,parent_id,text,answer\nx,-1,X,A\n", []string{"2: csv_number", "2: csv_number"}},
+		{"unknown column", "text,answer,comment\nX,A,B\n", []string{"csv_column_unknown"}},
+		{"missing column", "text\nX\n", []string{"csv_column_missing"}},
+		{"header only", "text,answer\n", []string{"empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseImport("questions.csv", []byte(tt.csv))
+			if got := problemKeys(err); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseImport() problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportCSVNesting(t *testing.T) {
+	csv := "row,parent_row,id,parent_id,lang,text,answer\n" +
+		"b,a,,,,Child,B\n" +
+		"a,,,12,en,Root,A\n" +
+		"c,a,5,,,Other child,C\n"
+	imp, err := ParseImport("questions.csv", []byte(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imp.Questions) != 1 {
+		t.Fatalf("got %d top-level questions, want 1", len(imp.Questions))
+	}
+	root := imp.Questions[0]
+	if root.Text != "Root" || root.ParentID != 12 || len(root.SubQuestions) != 2 {
+		t.Fatalf("root = %q under %d with %d sub-questions, want %q under 12 with 2", root.Text, root.ParentID, len(root.SubQuestions), "Root")
+	}
+	if sub := root.SubQuestions; sub[0].Text != "Child" || sub[1].Text != "Other child" || sub[1].ID != 5 {
+		t.Errorf("sub-questions = %q, %q #%d; want %q, %q #5", sub[0].Text, sub[1].Text, sub[1].ID, "Child", "Other child")
+	}
+}
+
+func TestImportValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{"valid tree", `
+- lang: en
+  text: Root
+  answer: A
+  sub_questions:
+    - text: Child
+      answer: B
+`, nil},
+		{"language differing from the parent", `
+- lang: en
+  text: Root
+  answer: A
+  sub_questions:
+    - lang: ru
+      text: Child
+      answer: B
+`, []string{`"Root" › "Child": lang_differs`}},
+		{"unknown language", `
+- lang: de
+  text: Root
+  answer: A
+`, []string{`"Root": lang_unknown`}},
+		{"missing fields", `
+- lang: en
+`, []string{"(1): text_missing", "(1): answer_missing"}},
+		{"duplicate id", `
+- {id: 3, lang: en, text: One, answer: A}
+- {id: 3, lang: en, text: Two, answer: B}
+`, []string{`"Two": id_duplicate`}},
+		{"duplicate siblings", `
+- {lang: en, text: Same, answer: A}
+- {lang: en, text: " Same ", answer: B}
+- {lang: ru, text: Same, answer: C}
+`, []string{`"Same": sibling_duplicate`}},
+		{"same text under different parents", `
+- lang: en
+  text: One
+  answer: A
+  sub_questions: [{text: Same, answer: B}]
+- lang: en
+  text: Two
+  answer: A
+  sub_questions: [{text: Same, answer: B}]
+`, nil},
+		{"parent_id of a sub-question", `
+- id: 4
+  lang: en
+  text: Root
+  answer: A
+  sub_questions: [{parent_id: 4, text: Child, answer: B}, {parent_id: 5, text: Other, answer: C}]
+`, []string{`"Root" › "Other": parent_mismatch`}},
+		{"attachments", `
+- {lang: en, text: One, answer: A, file_id: x}
+- {lang: en, text: Two, answer: A, file_type: doc}
+- {lang: en, text: Three, answer: A, file_type: video, file_id: x}
+`, []string{`"One": file_type_missing`, `"Two": file_id_missing`, `"Three": file_type_unknown`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := ParseImport("questions.yaml", []byte(tt.yaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := problemKeys(imp.Validate(hasTestLang)); !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportValidateInheritsLang(t *testing.T) {
+	imp, err := ParseImport("questions.yaml", []byte(`
+- lang: ru
+  text: Root
+  answer: A
+  sub_questions:
+    - text: Child
+      answer: B
+      sub_questions: [{text: Grandchild, answer: C}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imp.Validate(hasTestLang); err != nil {
+		t.Fatal(err)
+	}
+	child := imp.Questions[0].SubQuestions[0]
+	if child.Lang != "ru" || child.SubQuestions[0].Lang != "ru" {
+		t.Errorf("inherited languages = %q, %q; want ru", child.Lang, child.SubQuestions[0].Lang)
+	}
+}
+
+func TestImportErrorDescribe(t *testing.T) {
+	catalog, err := i18n.Load(locales.FS, []string{"en", "ru"}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	importErr := &ImportError{Problems: []ImportProblem{
+		{Line: 3, Key: "csv_parent_unknown", Args: []any{"7"}},
+		{Path: []string{`"Root"`, `"Child"`}, Key: "text_missing"},
+	}}
+	want := "line 3: parent_row \"7\" matches no row\n\"Root\" › \"Child\": text is missing"
+	if got := importErr.Describe(catalog, "en"); got != want {
+		t.Errorf("Describe(en) = %q, want %q", got, want)
+	}
+	if got := importErr.Describe(catalog, "ru"); !strings.HasPrefix(got, "строка 3: ") {
+		t.Errorf("Describe(ru) = %q, want it localized", got)
+	}
+
+	many := &ImportError{}
+	for range maxImportProblems + 3 {
+		many.Problems = append(many.Problems, ImportProblem{Key: "empty"})
+	}
+	lines := strings.Split(many.Describe(catalog, "en"), "\n")
+	if len(lines) != maxImportProblems+1 || lines[maxImportProblems] != "…and 3 more problems" {
+		t.Errorf("Describe() of %d problems = %d lines ending in %q", len(many.Problems), len(lines), lines[len(lines)-1])
+	}
+}
+
+// planImport parses, validates and plans a YAML import.
+func planImport(t *testing.T, repo BotRepository, data string) (*ImportPlan, error) {
+	t.Helper()
+
+	imp, err := ParseImport("questions.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imp.Validate(hasTestLang); err != nil {
+		t.Fatal(err)
+	}
+	return imp.Plan(context.Background(), repo)
+}
+
+func TestImportPlan(t *testing.T) {
+	ctx := context.Background()
+	repo := openTestRepository(t)
+
+	root := mustCreateQuestion(t, repo, "Root", 0)
+	child := mustCreateQuestion(t, repo, "Child", root)
+	mustCreateQuestion(t, repo, "Left alone", root)
+	other := mustCreateQuestion(t, repo, "Other", 0)
+
+	plan, err := planImport(t, repo, fmt.Sprintf(`
+- lang: en
+  text: Root
+  answer: Answer
+  sub_questions:
+    - {text: Child, answer: Changed}
+    - {text: New child, answer: Answer}
+- {id: %d, lang: en, text: Renamed, answer: Answer}
+- lang: en
+  text: New
+  answer: Answer
+  sub_questions: [{text: New grandchild, answer: Answer}]
+- {parent_id: %d, lang: en, text: Under child, answer: Answer}
+`, other, child))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type step struct {
+		action ImportAction
+		text   string
+		id     int
+		parent int
+	}
+	want := []step{
+		{ImportUnchanged, "Root", root, -1},
+		{ImportUpdate, "Child", child, -1},
+		{ImportCreate, "New child", 0, -1},
+		{ImportUpdate, "Renamed", other, -1},
+		{ImportCreate, "New", 0, -1},
+		{ImportCreate, "New grandchild", 0, 4},
+		{ImportCreate, "Under child", 0, -1},
+	}
+	var got []step
+	for _, s := range plan.Steps {
+		got = append(got, step{s.Action, s.Question.Text, s.Question.ID, s.Parent})
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("steps = %v, want %v", got, want)
+	}
+	if s := plan.Steps[2]; s.Question.ParentID != root {
+		t.Errorf("new child is under %d, want %d", s.Question.ParentID, root)
+	}
+
+	if _, err := planImport(t, repo, fmt.Sprintf("- {id: %d, lang: en, text: Child, answer: Answer}\n", child)); !slices.Equal(problemKeys(err), []string{"Child: moved"}) {
+		t.Errorf("moving question by id: problems = %q, want moved", problemKeys(err))
+	}
+	if _, err := planImport(t, repo, "- {parent_id: 1000, lang: en, text: X, answer: Answer}\n"); !slices.Equal(problemKeys(err), []string{"parent_not_found"}) {
+		t.Errorf("missing parent: problems = %q, want parent_not_found", problemKeys(err))
+	}
+	if _, err := planImport(t, repo, fmt.Sprintf("- {parent_id: %d, lang: ru, text: X, answer: Answer}\n", root)); !slices.Equal(problemKeys(err), []string{"parent_lang"}) {
+		t.Errorf("parent in another language: problems = %q, want parent_lang", problemKeys(err))
+	}
+
+	// Applying the plan leaves nothing to change
+	imp, err := ParseImport("questions.yaml", []byte("- lang: en\n  text: Root\n  answer: Answer\n  sub_questions: [{text: Child, answer: Changed}, {text: New child, answer: Answer}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imp.Validate(hasTestLang); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := imp.Apply(ctx, repo, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	again, err := imp.Plan(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := again.Count(ImportUnchanged); n != len(again.Steps) || n != 3 {
+		t.Errorf("after applying: %d of %d steps unchanged, want 3 of 3", n, len(again.Steps))
+	}
+}
+
+func TestImportExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := openTestRepository(t)
+	catalog, err := i18n.Load(locales.FS, []string{"en", "ru"}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := mustCreateQuestion(t, repo, "Root", 0)
+	mustCreateQuestion(t, repo, "Child", root)
+	if _, err := repo.CreateQuestion(ctx, "en", "With a file", "Answer\non two lines", fileTypeDoc, "file-1", root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateQuestion(ctx, "ru", "Корень", "Ответ: <b>да</b>", "", "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	langs, err := ExportQuestions(ctx, repo, catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{ExportJSON, ExportYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := EncodeExport(format, langs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imp, err := ParseImport("questions."+format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := imp.Validate(catalog.Has); err != nil {
+				t.Fatal(err)
+			}
+			plan, err := imp.Plan(ctx, repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := plan.Count(ImportUnchanged); n != len(plan.Steps) || n != 4 {
+				t.Errorf("%d of %d steps unchanged, want 4 of 4", n, len(plan.Steps))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// inlineCacheTime is how long Telegram may reuse inline results, in seconds.
+	inlineCacheTime = 60
+
+	// maxCaptionLength is Telegram's limit for media captions.
+	maxCaptionLength = 1024
+)
+
+// formatAnswer renders a question and its answer as a Markdown message.
+func formatAnswer(q *Question) string {
+	return fmt.Sprintf("*%s*\n\n%s", q.Text, q.Answer)
+}
+
+// isInlineQuery matches updates sent when the bot is mentioned in another chat.
+func isInlineQuery(update *models.Update) bool {
+	return update.InlineQuery != nil
+}
+
+// HandleInlineQuery answers "@bot <terms>" typed in any chat with the matching
+// questions; picking one inserts the question, its answer and attached file.
+func (b *Bot) HandleInlineQuery(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.InlineQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleInlineQuery received from user %d: %s\n", update.InlineQuery.From.ID, update.InlineQuery.Query)
+
+	results := []models.InlineQueryResult{}
+
+	lang := b.userLang(ctx, update.InlineQuery.From.ID)
+	found, err := b.findQuestions(ctx, lang, update.InlineQuery.Query)
+	if err != nil {
+		log.Println("failed to search questions: ", err)
+	} else {
+		// Spelling guesses are offered too; the result titles speak for themselves
+		for i := range found.Questions {
+			results = append(results, inlineResult(&found.Questions[i]))
+		}
+	}
+
+	_, err = tbot.AnswerInlineQuery(ctx, &tgbot.AnswerInlineQueryParams{
+		InlineQueryID: update.InlineQuery.ID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true, // results depend on the user's language
+	})
+	if err != nil {
+		log.Println("failed to answer inline query: ", err)
+	}
+}
+
+func inlineResult(q *Question) models.InlineQueryResult {
+	id := strconv.Itoa(q.ID)
+	description := truncateText(q.Answer, 100)
+
+	switch q.FileType {
+	case fileTypeDoc:
+		return &models.InlineQueryResultCachedDocument{
+			ID:             id,
+			Title:          q.Text,
+			DocumentFileID: q.FileID,
+			Description:    description,
+			Caption:        truncateText(formatAnswer(q), maxCaptionLength),
+			ParseMode:      models.ParseModeMarkdownV1,
+		}
+	case fileTypePhoto:
+		return &models.InlineQueryResultCachedPhoto{
+			ID:          id,
+			PhotoFileID: q.FileID,
+			Title:       q.Text,
+			Description: description,
+			Caption:     truncateText(formatAnswer(q), maxCaptionLength),
+			ParseMode:   models.ParseModeMarkdownV1,
+		}
+	}
+
+	return &models.InlineQueryResultArticle{
+		ID:          id,
+		Title:       q.Text,
+		Description: description,
+		InputMessageContent: &models.InputTextMessageContent{
+			MessageText: truncateText(formatAnswer(q), maxMessageLength),
+			ParseMode:   models.ParseModeMarkdownV1,
+		},
+	}
+}
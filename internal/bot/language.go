@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// detectLanguage is a middleware giving users who write to the bot for the
+// first time the language of their Telegram client, mapped to the configured
+// languages. /start links are left to HandleStartLink, which prefers the
+// language of the linked question.
+func (b *Bot) detectLanguage(next tgbot.HandlerFunc) tgbot.HandlerFunc {
+	return func(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+		if user := updateSender(update); user != nil && !isStartLink(update) {
+			b.ensureUserLang(ctx, user, "")
+		}
+		next(ctx, tbot, update)
+	}
+}
+
+func updateSender(update *models.Update) *models.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.From
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From
+	}
+	return nil
+}
+
+func isStartLink(update *models.Update) bool {
+	return update.Message != nil && strings.HasPrefix(update.Message.Text, "/start ")
+}
+
+// ensureUserLang stores a language for users who have none yet: preferred
+// when given, otherwise the one matching their Telegram client. Users are
+// looked up once per run.
+func (b *Bot) ensureUserLang(ctx context.Context, user *models.User, preferred string) {
+	if user == nil {
+		return
+	}
+	if _, ok := b.knownUsers.Load(user.ID); ok {
+		return
+	}
+
+	lang, err := b.repository.GetUserLang(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to get language of user %d: %v", user.ID, err)
+		return
+	}
+
+	if lang == "" {
+		switch {
+		case preferred != "":
+			lang = preferred
+		case user.LanguageCode != "":
+			lang = b.catalog.Match(user.LanguageCode)
+		}
+		if lang != "" {
+			if err := b.repository.SetUserLang(ctx, user.ID, lang); err != nil {
+				log.Printf("Failed to set language of user %d: %v", user.ID, err)
+				return
+			}
+		}
+	}
+	b.knownUsers.Store(user.ID, struct{}{})
+}
+
+// fallbackNotice returns the line telling a user that content is shown in a
+// fallback language, or "" when it is in their own.
+func (b *Bot) fallbackNotice(userLang, contentLang string) string {
+	if contentLang == userLang {
+		return ""
+	}
+	return b.t(userLang, "fallback.notice", b.catalog.Name(contentLang))
+}
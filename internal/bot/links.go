@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// startQuestionPrefix opens a question by its plain ID: /start q_<id>.
+	startQuestionPrefix = "q_"
+
+	// startSignedPrefix opens a question by an opaque slug that cannot be
+	// guessed without the link secret: /start s_<slug>.
+	startSignedPrefix = "s_"
+
+	// linkSignatureLength is the number of HMAC bytes kept in a slug.
+	linkSignatureLength = 8
+)
+
+// botUsername caches the bot's username, which deep links are built from.
+type botUsername struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (u *botUsername) get(ctx context.Context, tbot *tgbot.Bot) (string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.name != "" {
+		return u.name, nil
+	}
+	me, err := tbot.GetMe(ctx)
+	if err != nil {
+		return "", err
+	}
+	u.name = me.Username
+	return u.name, nil
+}
+
+// SetLinkSecret makes shared links use signed slugs instead of plain question
+// IDs. Links shared with another secret stop working, and so do plain q_<id>
+// links unless acceptPlainIDs keeps those shared before the secret was set
+// working.
+func (b *Bot) SetLinkSecret(secret string, acceptPlainIDs bool) {
+	b.linkSecret = []byte(secret)
+	b.acceptPlainLinks = acceptPlainIDs
+}
+
+// startPayload returns the /start parameter that opens a question.
+func (b *Bot) startPayload(id int) string {
+	if len(b.linkSecret) == 0 {
+		return startQuestionPrefix + strconv.Itoa(id)
+	}
+
+	raw := binary.AppendUvarint(nil, uint64(id))
+	raw = append(raw, b.linkSignature(raw)...)
+	return startSignedPrefix + base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (b *Bot) linkSignature(raw []byte) []byte {
+	mac := hmac.New(sha256.New, b.linkSecret)
+	mac.Write(raw)
+	return mac.Sum(nil)[:linkSignatureLength]
+}
+
+// parseStartPayload returns the question a /start parameter links to.
+func (b *Bot) parseStartPayload(payload string) (int, bool) {
+	if s, ok := strings.CutPrefix(payload, startQuestionPrefix); ok {
+		if len(b.linkSecret) != 0 && !b.acceptPlainLinks {
+			return 0, false
+		}
+		id, err := strconv.Atoi(s)
+		return id, err == nil && id > 0
+	}
+
+	s, ok := strings.CutPrefix(payload, startSignedPrefix)
+	if !ok || len(b.linkSecret) == 0 {
+		return 0, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) <= linkSignatureLength {
+		return 0, false
+	}
+
+	data, sig := raw[:len(raw)-linkSignatureLength], raw[len(raw)-linkSignatureLength:]
+	if !hmac.Equal(sig, b.linkSignature(data)) {
+		return 0, false
+	}
+	id, n := binary.Uvarint(data)
+	if n != len(data) || id == 0 || id > uint64(^uint32(0)>>1) {
+		return 0, false
+	}
+	return int(id), true
+}
+
+// questionLink returns the t.me link that opens a question in the bot.
+func (b *Bot) questionLink(ctx context.Context, tbot *tgbot.Bot, id int) (string, error) {
+	username, err := b.username.get(ctx, tbot)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", username, b.startPayload(id)), nil
+}
+
+// HandleStartLink opens the question a /start payload links to. It reports
+// false when the payload is not a question link.
+func (b *Bot) HandleStartLink(ctx context.Context, tbot *tgbot.Bot, update *models.Update, payload string) bool {
+	id, ok := b.parseStartPayload(payload)
+	if !ok {
+		return false
+	}
+
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+
+	q, err := b.repository.GetQuestionByID(ctx, id)
+	if err != nil {
+		b.ensureUserLang(ctx, update.Message.From, "")
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(b.userLang(ctx, userID), "link.not_found")})
+		return true
+	}
+
+	// New users get the language of the question they were sent; others keep
+	// theirs and see the question marked as being in another language
+	b.ensureUserLang(ctx, update.Message.From, q.Lang)
+
+	b.sendQuestion(ctx, tbot, chatID, userID, q)
+	return true
+}
+
+// HandleShareCallback replies with a link to the question that can be sent
+// to other users.
+func (b *Bot) HandleShareCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleShareCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "share_"))
+	if err != nil {
+		return
+	}
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	lang := b.userLang(ctx, update.CallbackQuery.From.ID)
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "link.not_found")})
+		return
+	}
+
+	link, err := b.questionLink(ctx, tbot, q.ID)
+	if err != nil {
+		log.Println("failed to build question link: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "link.failed")})
+		return
+	}
+
+	shareURL := "https://t.me/share/url?" + url.Values{"url": {link}, "text": {q.Text}}.Encode()
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   b.t(lang, "link.share", q.Text, link),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: b.t(lang, "link.forward"), URL: shareURL}},
+			},
+		},
+	})
+}
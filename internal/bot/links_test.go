@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestStartPayloadRoundTrip(t *testing.T) {
+	plain := &Bot{}
+	signed := &Bot{}
+	signed.SetLinkSecret("secret", false)
+	other := &Bot{}
+	other.SetLinkSecret("other secret", false)
+
+	for _, id := range []int{1, 127, 128, 1 << 20, 1<<31 - 1} {
+		for name, b := range map[string]*Bot{"plain": plain, "signed": signed} {
+			payload := b.startPayload(id)
+			if got, ok := b.parseStartPayload(payload); !ok || got != id {
+				t.Errorf("%s: parseStartPayload(%q) = %d, %v; want %d", name, payload, got, ok, id)
+			}
+		}
+
+		payload := signed.startPayload(id)
+		if !strings.HasPrefix(payload, startSignedPrefix) {
+			t.Errorf("signed payload %q lacks the %q prefix", payload, startSignedPrefix)
+		}
+		if _, ok := other.parseStartPayload(payload); ok {
+			t.Errorf("payload %q accepted with another secret", payload)
+		}
+		if _, ok := plain.parseStartPayload(payload); ok {
+			t.Errorf("payload %q accepted without a secret", payload)
+		}
+	}
+}
+
+func TestParseStartPayload(t *testing.T) {
+	signed := &Bot{}
+	signed.SetLinkSecret("secret", false)
+	lenient := &Bot{}
+	lenient.SetLinkSecret("secret", true)
+
+	valid := signed.startPayload(42)
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(valid, startSignedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamper := func(i int) string {
+		b := append([]byte(nil), raw...)
+		b[i] ^= 1
+		return startSignedPrefix + base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name    string
+		bot     *Bot
+		payload string
+		want    int
+		ok      bool
+	}{
+		{"plain ID without a secret", &Bot{}, "q_42", 42, true},
+		{"plain ID with a secret", signed, "q_42", 0, false},
+		{"plain ID with a secret and plain IDs accepted", lenient, "q_42", 42, true},
+		{"signed slug with plain IDs accepted", lenient, valid, 42, true},
+		{"zero ID", &Bot{}, "q_0", 0, false},
+		{"negative ID", &Bot{}, "q_-1", 0, false},
+		{"non-numeric ID", &Bot{}, "q_abc", 0, false},
+		{"tampered ID", signed, tamper(0), 0, false},
+		{"tampered signature", signed, tamper(len(raw) - 1), 0, false},
+		{"truncated slug", signed, valid[:len(valid)-2], 0, false},
+		{"slug that is not base64", signed, startSignedPrefix + "!!!", 0, false},
+		{"empty slug", signed, startSignedPrefix, 0, false},
+		{"unknown prefix", signed, "x_42", 0, false},
+		{"empty payload", signed, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.bot.parseStartPayload(tt.payload)
+			if ok != tt.ok || ok && got != tt.want {
+				t.Fatalf("parseStartPayload(%q) = %d, %v; want %d, %v", tt.payload, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
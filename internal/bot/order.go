@@ -0,0 +1,329 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// questionPlacement is the audit payload of reorders and moves. Position
+// counts from 1 among the siblings.
+type questionPlacement struct {
+	ParentID int `json:"parent_id"`
+	Position int `json:"position,omitempty"`
+}
+
+// siblings returns the questions on the level of q, in order, together with
+// their parent (nil at the top level).
+func (b *Bot) siblings(ctx context.Context, q *Question) ([]Question, *Question, error) {
+	if q.ParentID != 0 {
+		parent, err := b.repository.GetQuestionByID(ctx, q.ParentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return parent.SubQuestions, parent, nil
+	}
+	questions, err := b.repository.GetQuestionsByLang(ctx, q.Lang)
+	return questions, nil, err
+}
+
+func questionIndex(questions []Question, id int) int {
+	for i, q := range questions {
+		if q.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// HandleReorderCallback moves a question one place up (up_<id>) or down
+// (down_<id>) among its siblings and redraws the keyboard it was tapped in.
+func (b *Bot) HandleReorderCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleReorderCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	userID := update.CallbackQuery.From.ID
+	direction, idStr, _ := strings.Cut(update.CallbackQuery.Data, "_")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
+	}
+
+	siblings, _, err := b.siblings(ctx, q)
+	if err != nil {
+		log.Println("failed to load sibling questions: ", err)
+		return
+	}
+	i := questionIndex(siblings, id)
+	j := i - 1
+	if direction == "down" {
+		j = i + 1
+	}
+	if i < 0 || j < 0 || j >= len(siblings) {
+		return
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.SwapQuestions(ctx, id, siblings[j].ID); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionReorder, QuestionID: id},
+			questionPlacement{ParentID: q.ParentID, Position: i + 1}, questionPlacement{ParentID: q.ParentID, Position: j + 1})
+	})
+	if err != nil {
+		log.Println("failed to reorder questions: ", err)
+		return
+	}
+
+	siblings, parent, err := b.siblings(ctx, q)
+	if err != nil {
+		log.Println("failed to load sibling questions: ", err)
+		return
+	}
+
+	lang := b.userLang(ctx, userID)
+	access := b.questionAccess(ctx, userID, q.ParentID)
+	keyboard := b.buildQuestionKeyboard(lang, siblings, q.ParentID, j/pageSize, pageSize, access)
+	if parent != nil {
+		b.addTranslationsButton(lang, keyboard, parent)
+	}
+
+	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID:   update.CallbackQuery.Message.Message.ID,
+		ReplyMarkup: keyboard,
+	})
+}
+
+// HandleMoveCallback starts moving a question (mv_<id>) by showing the
+// question tree to pick its new parent from.
+func (b *Bot) HandleMoveCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleMoveCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	userID := update.CallbackQuery.From.ID
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "mv_"))
+	if err != nil {
+		return
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
+	}
+
+	text, keyboard, err := b.movePicker(ctx, userID, q, 0)
+	if err != nil {
+		log.Println("failed to build move picker: ", err)
+		return
+	}
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	})
+}
+
+// HandleMoveNavigate shows another level of the tree in the move picker:
+// mvnav_<id>_<level>, where level is the question whose children are shown.
+func (b *Bot) HandleMoveNavigate(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleMoveNavigate received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	userID := update.CallbackQuery.From.ID
+	id, level, ok := parseMoveCallback(update.CallbackQuery.Data, "mvnav_")
+	if !ok {
+		return
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
+	}
+
+	text, keyboard, err := b.movePicker(ctx, userID, q, level)
+	if err != nil {
+		log.Println("failed to build move picker: ", err)
+		return
+	}
+	tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID:   update.CallbackQuery.Message.Message.ID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	})
+}
+
+// HandleMoveConfirm moves a question under the picked parent: mvto_<id>_<parent>.
+func (b *Bot) HandleMoveConfirm(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleMoveConfirm received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	msgID := update.CallbackQuery.Message.Message.ID
+
+	id, parentID, ok := parseMoveCallback(update.CallbackQuery.Data, "mvto_")
+	if !ok {
+		return
+	}
+
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil || !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return
+	}
+
+	lang := b.userLang(ctx, userID)
+	done := b.t(lang, "move.done_top", q.Text)
+	if parentID != 0 {
+		parent, err := b.repository.GetQuestionShallow(ctx, parentID)
+		if err != nil {
+			tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{ChatID: chatID, MessageID: msgID, Text: b.t(lang, "common.question_not_found", parentID)})
+			return
+		}
+		if parent.Lang != q.Lang {
+			return
+		}
+		done = b.t(lang, "move.done", q.Text, parent.Text)
+	}
+	if !b.auth.CanAdd(ctx, userID, q.Lang, parentID) {
+		tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{ChatID: chatID, MessageID: msgID, Text: b.t(lang, "move.not_allowed")})
+		return
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.MoveQuestion(ctx, id, parentID); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionMove, QuestionID: id},
+			questionPlacement{ParentID: q.ParentID}, questionPlacement{ParentID: parentID})
+	})
+	switch {
+	case errors.Is(err, ErrQuestionCycle):
+		tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{ChatID: chatID, MessageID: msgID, Text: b.t(lang, "move.cycle")})
+		return
+	case errors.Is(err, ErrParentDeleted):
+		tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{ChatID: chatID, MessageID: msgID, Text: b.t(lang, "move.parent_deleted")})
+		return
+	case err != nil:
+		log.Println("failed to move question: ", err)
+		tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{ChatID: chatID, MessageID: msgID, Text: b.t(lang, "move.failed")})
+		return
+	}
+
+	tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: msgID,
+		Text:      done,
+	})
+}
+
+// HandleMoveCancel closes the move picker.
+func (b *Bot) HandleMoveCancel(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+	tbot.EditMessageText(ctx, &tgbot.EditMessageTextParams{
+		ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+		Text:      b.t(b.userLang(ctx, update.CallbackQuery.From.ID), "move.cancelled"),
+	})
+}
+
+func parseMoveCallback(data, prefix string) (id, level int, ok bool) {
+	idStr, levelStr, found := strings.Cut(strings.TrimPrefix(data, prefix), "_")
+	if !found {
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	level, err = strconv.Atoi(levelStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	return id, level, true
+}
+
+// movePicker renders one level of the tree for picking the new parent of q.
+// The question itself is left out, so its subtree cannot be entered.
+func (b *Bot) movePicker(ctx context.Context, userID int64, q *Question, level int) (string, *models.InlineKeyboardMarkup, error) {
+	var (
+		children []Question
+		current  *Question
+		err      error
+	)
+	if level == 0 {
+		children, err = b.repository.GetQuestionsByLang(ctx, q.Lang)
+	} else {
+		current, err = b.repository.GetQuestionByID(ctx, level)
+		if err == nil {
+			children = current.SubQuestions
+		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for _, c := range children {
+		if c.ID == q.ID {
+			continue
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: "📂 " + c.Text, CallbackData: fmt.Sprintf("mvnav_%d_%d", q.ID, c.ID)},
+		})
+	}
+
+	lang := b.userLang(ctx, userID)
+
+	var actions []models.InlineKeyboardButton
+	if level != q.ParentID && b.auth.CanAdd(ctx, userID, q.Lang, level) {
+		actions = append(actions, models.InlineKeyboardButton{Text: b.t(lang, "move.here"), CallbackData: fmt.Sprintf("mvto_%d_%d", q.ID, level)})
+	}
+	if current != nil {
+		actions = append(actions, models.InlineKeyboardButton{Text: b.t(lang, "move.up"), CallbackData: fmt.Sprintf("mvnav_%d_%d", q.ID, current.ParentID)})
+	}
+	actions = append(actions, models.InlineKeyboardButton{Text: b.t(lang, "common.cancel"), CallbackData: "mvno_"})
+	rows = append(rows, actions)
+
+	text := b.t(lang, "move.picker_top", q.Text, q.ID)
+	if current != nil {
+		text = b.t(lang, "move.picker", q.Text, q.ID, current.Text)
+	}
+	return text, &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
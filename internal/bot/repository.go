@@ -4,59 +4,115 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"qaBot/pkg/textsearch"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository struct {
-	db *pgxpool.Pool
+	db pgxQuerier
+}
+
+// pgxQuerier is what the repository runs its statements on: the pool, or a
+// transaction inside WithTx.
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+func (r *Repository) WithTx(ctx context.Context, fn func(tx BotRepository) error) error {
+	if _, ok := r.db.(pgx.Tx); ok {
+		return fn(r)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rollback is a no-op once the transaction is committed
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Repository{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetQuestionsByLang returns the top-level questions of a language with
+// their whole subtrees, loaded in a single query.
 func (r *Repository) GetQuestionsByLang(ctx context.Context, lang string) ([]Question, error) {
-	questions := []Question{}
+	return r.getQuestionTree(ctx, "lang = $1 AND parent_id IS NULL", lang)
+}
 
-	// Fetch top-level questions (parent_id is NULL)
-	rows, err := r.db.Query(ctx, "SELECT id, lang, text, answer, file_type, file_id, parent_id FROM questions WHERE lang = $1 order by id", lang)
+// GetQuestionLanguages returns the languages that have top-level questions,
+// in alphabetical order.
+func (r *Repository) GetQuestionLanguages(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT lang FROM questions
+        WHERE parent_id IS NULL AND deleted_at IS NULL ORDER BY lang`)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 	defer rows.Close()
 
+	langs := []string{}
 	for rows.Next() {
-		var (
-			q  Question
-			id sql.NullInt32
-		)
-
-		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &id); err != nil {
-			log.Printf("Failed to scan question: %v", err)
-			continue
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, err
 		}
-		q.ParentID = int(id.Int32)
+		langs = append(langs, lang)
+	}
+	return langs, rows.Err()
+}
 
-		// Fetch subquestions for this question
-		q.SubQuestions, err = r.GetSubQuestions(ctx, q.ID)
-		if err != nil {
-			log.Printf("Failed to fetch subquestions for question ID %d: %v", q.ID, err)
-		}
+// getQuestionTree loads the questions matching rootCondition with all their
+// descendants, see questionTreeQuery.
+func (r *Repository) getQuestionTree(ctx context.Context, rootCondition string, args ...any) ([]Question, error) {
+	rows, err := r.db.Query(ctx, questionTreeQuery(rootCondition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group, &q.Version); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
 		questions = append(questions, q)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return questions, nil
+	return buildQuestionTree(questions), nil
 }
 
 func (r *Repository) GetSubQuestions(ctx context.Context, parentID int) ([]Question, error) {
 	subQuestions := []Question{}
 
-	rows, err := r.db.Query(ctx, "SELECT id, lang, text, answer, file_type, file_id, parent_id FROM questions WHERE parent_id = $1 order by id", parentID)
+	rows, err := r.db.Query(ctx, "SELECT id, lang, text, answer, file_type, file_id, parent_id FROM questions WHERE parent_id = $1 AND deleted_at IS NULL ORDER BY position, id", parentID)
 	if err != nil {
 		return nil, err
 	}
@@ -93,53 +149,69 @@ func (r *Repository) GetUserLang(ctx context.Context, userID int64) (string, err
 	return lang, err
 }
 
-// GetQuestionByID retrieves a question (with subquestions) by its ID.
+// GetQuestionByID retrieves a question with its whole subtree by its ID.
 func (r *Repository) GetQuestionByID(ctx context.Context, id int) (*Question, error) {
+	tree, err := r.getQuestionTree(ctx, "id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &tree[0], nil
+}
+
+// GetQuestionShallow returns a question without its sub-questions, for
+// callers that only need the question itself.
+func (r *Repository) GetQuestionShallow(ctx context.Context, id int) (*Question, error) {
 	var (
 		q        Question
 		parentID sql.NullInt32
+		group    sql.NullInt32
 	)
-	err := r.db.QueryRow(ctx, "SELECT id, lang, text, answer, file_type, file_id, parent_id FROM questions WHERE id = $1", id).
-		Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID)
+	err := r.db.QueryRow(ctx, `SELECT id, lang, text, answer, file_type, file_id, parent_id, translation_group, version
+        FROM questions WHERE id = $1 AND deleted_at IS NULL`, id).
+		Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group, &q.Version)
 	if err != nil {
 		return nil, err
 	}
-
 	q.ParentID = int(parentID.Int32)
-
-	// Fetch subquestions for this question
-	q.SubQuestions, err = r.GetSubQuestions(ctx, q.ID)
-	if err != nil {
-		log.Printf("Failed to fetch subquestions for question ID %d: %v", q.ID, err)
-	}
-
+	q.TranslationGroup = int(group.Int32)
 	return &q, nil
 }
 
-func (r *Repository) DeleteQuestionByID(ctx context.Context, id int) error {
-	qs, err := r.GetSubQuestions(ctx, id)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return err
-	}
-
-	for _, q := range qs {
-		r.DeleteQuestionByID(ctx, q.ID)
-	}
-
-	_, err = r.db.Exec(ctx, "DELETE FROM questions WHERE id = $1", id)
+// DeleteQuestionByID soft-deletes a question together with its subtree. The
+// rows keep the ID of the deleted root so the subtree can be restored as a whole.
+func (r *Repository) DeleteQuestionByID(ctx context.Context, id int, deletedBy int64) error {
+	tag, err := r.db.Exec(ctx, `UPDATE questions SET deleted_at = $2, deleted_by = $3, deleted_root_id = $1
+        WHERE id IN (
+            WITH RECURSIVE subtree(id) AS (
+                SELECT id FROM questions WHERE id = $1 AND deleted_at IS NULL
+                UNION ALL
+                SELECT q.id FROM questions q JOIN subtree s ON q.parent_id = s.id WHERE q.deleted_at IS NULL
+            )
+            SELECT id FROM subtree
+        )`, id, time.Now().UTC(), deletedBy)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrQuestionNotFound
+	}
 
 	return nil
 }
 
-// CreateQuestion inserts a new question into the questions table.
-func (r *Repository) CreateQuestion(ctx context.Context, lang, text, answer string, parentID int) (int, error) {
+// CreateQuestion inserts a new question, with its attachment if any, into the
+// questions table.
+func (r *Repository) CreateQuestion(ctx context.Context, lang, text, answer, fileType, fileID string, parentID int) (int, error) {
 	row := r.db.QueryRow(
 		ctx,
-		"INSERT INTO questions (lang, text, answer, parent_id) VALUES ($1, $2, $3, $4) RETURNING id",
-		lang, text, answer, sql.NullInt32{Int32: int32(parentID), Valid: parentID != 0},
+		`INSERT INTO questions (lang, text, answer, file_type, file_id, parent_id, position)
+        VALUES ($1, $2, $3, $4, $5, $6, (
+            SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE parent_id IS NOT DISTINCT FROM $6 AND lang = $1
+        )) RETURNING id`,
+		lang, text, answer, fileType, fileID, sql.NullInt32{Int32: int32(parentID), Valid: parentID != 0},
 	)
 	var id int32
 
@@ -149,11 +221,62 @@ func (r *Repository) CreateQuestion(ctx context.Context, lang, text, answer stri
 	return int(id), nil
 }
 
+// SwapQuestions exchanges the positions of two questions, which should be
+// siblings.
+func (r *Repository) SwapQuestions(ctx context.Context, id, otherID int) error {
+	_, err := r.db.Exec(ctx, `UPDATE questions
+        SET position = (SELECT SUM(position) FROM questions WHERE id IN ($1, $2)) - position
+        WHERE id IN ($1, $2)`, id, otherID)
+	return err
+}
+
+// MoveQuestion puts a question, with its subtree, last under a new parent; 0
+// moves it to the top level. A question cannot be moved under itself or one
+// of its descendants, nor under a deleted question.
+func (r *Repository) MoveQuestion(ctx context.Context, id, parentID int) error {
+	if parentID != 0 {
+		var parentDeleted bool
+		err := r.db.QueryRow(ctx, "SELECT deleted_at IS NOT NULL FROM questions WHERE id = $1", parentID).Scan(&parentDeleted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrQuestionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if parentDeleted {
+			return ErrParentDeleted
+		}
+
+		path, err := r.GetQuestionPath(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(path, id) {
+			return ErrQuestionCycle
+		}
+	}
+
+	tag, err := r.db.Exec(ctx, `UPDATE questions SET parent_id = $2, position = (
+            SELECT COALESCE(MAX(s.position), 0) + 1 FROM questions s WHERE s.parent_id IS NOT DISTINCT FROM $2 AND s.lang = questions.lang
+        )
+        WHERE id = $1 AND deleted_at IS NULL
+            AND ($2::int IS NULL OR EXISTS (SELECT 1 FROM questions p WHERE p.id = $2 AND p.deleted_at IS NULL))`, id, sql.NullInt32{Int32: int32(parentID), Valid: parentID != 0})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrQuestionNotFound
+	}
+	return nil
+}
+
 // UpdateQuestion updates the text and answer of a question by its ID.
 func (r *Repository) UpdateQuestion(ctx context.Context, id int, text, answer string) error {
 	_, err := r.db.Exec(
 		ctx,
-		"UPDATE questions SET text = $1, answer = $2 WHERE id = $3",
+		`UPDATE questions SET text = $1, answer = $2,
+            version = version + CASE WHEN text <> $1 OR answer <> $2 THEN 1 ELSE 0 END
+        WHERE id = $3`,
 		text, answer, id,
 	)
 	return err
@@ -161,6 +284,565 @@ func (r *Repository) UpdateQuestion(ctx context.Context, id int, text, answer st
 
 // UpdateQuestionFileID updates the file_id of a question by its ID.
 func (r *Repository) UpdateQuestionFile(ctx context.Context, id int, fileType, fileID string) error {
-	_, err := r.db.Exec(ctx, "UPDATE questions SET file_type = $1, file_id = $2 WHERE id = $3", fileType, fileID, id)
+	_, err := r.db.Exec(ctx, `UPDATE questions SET file_type = $1, file_id = $2,
+            version = version + CASE WHEN file_type <> $1 OR file_id <> $2 THEN 1 ELSE 0 END
+        WHERE id = $3`, fileType, fileID, id)
+	return err
+}
+
+// CheckQuestionVersion locks a question for the rest of the transaction and
+// returns ErrVersionConflict unless it is still at the given version.
+func (r *Repository) CheckQuestionVersion(ctx context.Context, id, version int) error {
+	var current int32
+	err := r.db.QueryRow(ctx, "SELECT version FROM questions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if int(current) != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// GetAdminRole returns the role assigned to the user, or "" if the user is not an admin.
+func (r *Repository) GetAdminRole(ctx context.Context, userID int64) (Role, error) {
+	var role Role
+	err := r.db.QueryRow(ctx, "SELECT role FROM admins WHERE user_id = $1", userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// SetAdminRole grants a role to the user, replacing any previous one.
+func (r *Repository) SetAdminRole(ctx context.Context, userID int64, role Role) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO admins (user_id, role) VALUES ($1, $2)
+        ON CONFLICT(user_id) DO UPDATE SET role=excluded.role`,
+		userID, role)
+	return err
+}
+
+// RemoveAdmin revokes all admin rights of the user.
+func (r *Repository) RemoveAdmin(ctx context.Context, userID int64) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM admins WHERE user_id = $1", userID)
+	return err
+}
+
+// ListAdmins returns all admins ordered by the time they were added.
+func (r *Repository) ListAdmins(ctx context.Context) ([]Admin, error) {
+	rows, err := r.db.Query(ctx, "SELECT user_id, role, created_at FROM admins ORDER BY created_at, user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	admins := []Admin{}
+	for rows.Next() {
+		var a Admin
+		if err := rows.Scan(&a.UserID, &a.Role, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		admins = append(admins, a)
+	}
+
+	return admins, rows.Err()
+}
+
+// AddAdminScope restricts an admin's rights to a language and/or subtree.
+func (r *Repository) AddAdminScope(ctx context.Context, scope Scope) (int, error) {
+	var id int32
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO admin_scopes (user_id, lang, root_id, actions) VALUES ($1, $2, $3, $4) RETURNING id",
+		scope.UserID, scope.Lang, scope.RootID, joinActions(scope.Actions),
+	).Scan(&id)
+	return int(id), err
+}
+
+// ListAdminScopes returns the scopes of one admin, or of every admin when userID is 0.
+func (r *Repository) ListAdminScopes(ctx context.Context, userID int64) ([]Scope, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT id, user_id, lang, root_id, actions FROM admin_scopes WHERE $1::bigint = 0 OR user_id = $1 ORDER BY user_id, id",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scopes := []Scope{}
+	for rows.Next() {
+		var (
+			s       Scope
+			actions string
+		)
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Lang, &s.RootID, &actions); err != nil {
+			return nil, err
+		}
+		s.Actions = splitActions(actions)
+		scopes = append(scopes, s)
+	}
+
+	return scopes, rows.Err()
+}
+
+// DeleteAdminScope removes a single scope by its ID.
+func (r *Repository) DeleteAdminScope(ctx context.Context, id int) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM admin_scopes WHERE id = $1", id)
+	return err
+}
+
+// GetQuestionPath returns the question ID followed by the IDs of all its ancestors.
+func (r *Repository) GetQuestionPath(ctx context.Context, id int) ([]int, error) {
+	rows, err := r.db.Query(ctx, `WITH RECURSIVE path(id, parent_id, depth) AS (
+            SELECT id, parent_id, 0 FROM questions WHERE id = $1
+            UNION ALL
+            SELECT q.id, q.parent_id, p.depth + 1 FROM questions q JOIN path p ON q.id = p.parent_id
+        )
+        SELECT id FROM path ORDER BY depth`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	path := []int{}
+	for rows.Next() {
+		var qID int32
+		if err := rows.Scan(&qID); err != nil {
+			return nil, err
+		}
+		path = append(path, int(qID))
+	}
+
+	return path, rows.Err()
+}
+
+// GetDescendants returns every non-deleted question below the given one, breadth first.
+func (r *Repository) GetDescendants(ctx context.Context, id int) ([]Question, error) {
+	rows, err := r.db.Query(ctx, `WITH RECURSIVE subtree(id, depth) AS (
+            SELECT id, 1 FROM questions WHERE parent_id = $1 AND deleted_at IS NULL
+            UNION ALL
+            SELECT q.id, s.depth + 1 FROM questions q JOIN subtree s ON q.parent_id = s.id WHERE q.deleted_at IS NULL
+        )
+        SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id
+        FROM questions q JOIN subtree s ON q.id = s.id
+        ORDER BY s.depth, q.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	descendants := []Question{}
+	for rows.Next() {
+		var q Question
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &q.ParentID); err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, q)
+	}
+
+	return descendants, rows.Err()
+}
+
+// AddRevision stores a snapshot of a question after a change.
+func (r *Repository) AddRevision(ctx context.Context, rev Revision) (int, error) {
+	var id int32
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO question_revisions (question_id, action, text, answer, file_type, file_id, author_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		rev.QuestionID, rev.Action, rev.Text, rev.Answer, rev.FileType, rev.FileID, rev.AuthorID,
+	).Scan(&id)
+	return int(id), err
+}
+
+// ListRevisions returns the revisions of a question, newest first.
+func (r *Repository) ListRevisions(ctx context.Context, questionID int) ([]Revision, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT id, question_id, action, text, answer, file_type, file_id, author_id, created_at FROM question_revisions WHERE question_id = $1 ORDER BY id DESC",
+		questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var rev Revision
+		if err := rows.Scan(&rev.ID, &rev.QuestionID, &rev.Action, &rev.Text, &rev.Answer, &rev.FileType, &rev.FileID, &rev.AuthorID, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieves a single revision by its ID.
+func (r *Repository) GetRevision(ctx context.Context, id int) (*Revision, error) {
+	var rev Revision
+	err := r.db.QueryRow(ctx,
+		"SELECT id, question_id, action, text, answer, file_type, file_id, author_id, created_at FROM question_revisions WHERE id = $1",
+		id).Scan(&rev.ID, &rev.QuestionID, &rev.Action, &rev.Text, &rev.Answer, &rev.FileType, &rev.FileID, &rev.AuthorID, &rev.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// ListDeletedQuestions returns the roots of deleted subtrees, most recently deleted first.
+func (r *Repository) ListDeletedQuestions(ctx context.Context) ([]DeletedQuestion, error) {
+	rows, err := r.db.Query(ctx, `SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id,
+            q.deleted_at, q.deleted_by,
+            (SELECT COUNT(*) FROM questions d WHERE d.deleted_root_id = q.id AND d.id <> q.id)
+        FROM questions q
+        WHERE q.deleted_root_id = q.id
+        ORDER BY q.deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := []DeletedQuestion{}
+	for rows.Next() {
+		var (
+			d        DeletedQuestion
+			parentID sql.NullInt32
+		)
+		if err := rows.Scan(&d.ID, &d.Lang, &d.Text, &d.Answer, &d.FileType, &d.FileID, &parentID,
+			&d.DeletedAt, &d.DeletedBy, &d.Descendants); err != nil {
+			return nil, err
+		}
+		d.ParentID = int(parentID.Int32)
+		deleted = append(deleted, d)
+	}
+
+	return deleted, rows.Err()
+}
+
+// RestoreQuestion restores a deleted subtree under its original parent.
+func (r *Repository) RestoreQuestion(ctx context.Context, id int) error {
+	var parentDeleted bool
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(p.deleted_at IS NOT NULL, false)
+        FROM questions q LEFT JOIN questions p ON p.id = q.parent_id
+        WHERE q.id = $1 AND q.deleted_root_id = q.id`, id).Scan(&parentDeleted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if parentDeleted {
+		return ErrParentDeleted
+	}
+
+	_, err = r.db.Exec(ctx,
+		"UPDATE questions SET deleted_at = NULL, deleted_by = NULL, deleted_root_id = NULL WHERE deleted_root_id = $1",
+		id)
+	return err
+}
+
+// PurgeDeletedQuestions hard-deletes questions deleted before the given time.
+func (r *Repository) PurgeDeletedQuestions(ctx context.Context, before time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx, "DELETE FROM questions WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// AddAuditEntry appends an entry to the audit log.
+func (r *Repository) AddAuditEntry(ctx context.Context, entry AuditEntry) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO audit_log (actor_id, action, question_id, target_user_id, payload_before, payload_after, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ActorID, entry.Action, nullInt(int64(entry.QuestionID)), nullInt(entry.TargetUserID),
+		entry.Before, entry.After, time.Now().UTC())
+	return err
+}
+
+// ListAuditEntries returns audit entries matching the filter, newest first.
+func (r *Repository) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	where, args := auditConditions(filter, func(n int) string { return fmt.Sprintf("$%d", n) })
+	query := "SELECT id, actor_id, action, question_id, target_user_id, payload_before, payload_after, created_at FROM audit_log" +
+		where + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var (
+			e            AuditEntry
+			questionID   sql.NullInt32
+			targetUserID sql.NullInt64
+		)
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &questionID, &targetUserID, &e.Before, &e.After, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.QuestionID = int(questionID.Int32)
+		e.TargetUserID = targetUserID.Int64
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// auditConditions builds the WHERE clause of an audit query using the
+// dialect's placeholder style.
+func auditConditions(filter AuditFilter, placeholder func(n int) string) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, cond+" "+placeholder(len(args)))
+	}
+
+	if filter.ActorID != 0 {
+		add("actor_id =", filter.ActorID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >=", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("created_at <", filter.To.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// nullInt stores 0 as NULL for optional references.
+func nullInt(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+// SearchQuestions runs a full-text search over the text and answer of the
+// questions in one language, best matches first.
+func (r *Repository) SearchQuestions(ctx context.Context, lang string, query textsearch.Query, limit int) ([]Question, error) {
+	if len(query) == 0 {
+		return []Question{}, nil
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT id, lang, text, answer, file_type, file_id, parent_id
+        FROM questions, to_tsquery(question_search_config($1), $2) query
+        WHERE lang = $1 AND deleted_at IS NULL AND search_vector @@ query
+        ORDER BY ts_rank(search_vector, query) DESC, id
+        LIMIT $3`, lang, query.TSQuery(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// ListQuestions returns every question in a language, or in all languages
+// when lang is empty, without their sub-questions.
+func (r *Repository) ListQuestions(ctx context.Context, lang string) ([]Question, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, lang, text, answer, file_type, file_id, parent_id, translation_group FROM questions
+        WHERE ($1 = '' OR lang = $1) AND deleted_at IS NULL ORDER BY id`, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// GetTranslations returns the other questions in the translation group of a
+// question, without their sub-questions.
+func (r *Repository) GetTranslations(ctx context.Context, id int) ([]Question, error) {
+	rows, err := r.db.Query(ctx, `SELECT t.id, t.lang, t.text, t.answer, t.file_type, t.file_id, t.parent_id, t.translation_group
+        FROM questions q JOIN questions t ON t.translation_group = q.translation_group
+        WHERE q.id = $1 AND t.id <> q.id AND t.deleted_at IS NULL
+        ORDER BY t.lang, t.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// LinkTranslations puts two questions, together with the translations each
+// already has, into one translation group.
+func (r *Repository) LinkTranslations(ctx context.Context, id, otherID int) error {
+	_, err := r.db.Exec(ctx, `WITH target AS (
+            SELECT COALESCE(
+                (SELECT translation_group FROM questions WHERE id = $2),
+                (SELECT translation_group FROM questions WHERE id = $1),
+                nextval('translation_group_seq')
+            ) AS grp
+        )
+        UPDATE questions SET translation_group = (SELECT grp FROM target)
+        WHERE id IN ($1, $2) OR translation_group IN (
+            SELECT translation_group FROM questions WHERE id IN ($1, $2) AND translation_group IS NOT NULL
+        )`, id, otherID)
+	return err
+}
+
+// UnlinkTranslation removes a question from its translation group. A group
+// left with a single question is dissolved.
+func (r *Repository) UnlinkTranslation(ctx context.Context, id int) error {
+	if _, err := r.db.Exec(ctx, "UPDATE questions SET translation_group = NULL WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(ctx, `UPDATE questions SET translation_group = NULL WHERE translation_group IN (
+            SELECT translation_group FROM questions WHERE translation_group IS NOT NULL
+            GROUP BY translation_group HAVING COUNT(*) = 1
+        )`)
+	return err
+}
+
+func (r *Repository) AddSynonym(ctx context.Context, s Synonym) (int, error) {
+	var id int32
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO synonyms (lang, term, synonym) VALUES ($1, $2, $3) RETURNING id",
+		s.Lang, s.Term, s.Synonym,
+	).Scan(&id)
+	return int(id), err
+}
+
+// ListSynonyms returns the synonyms of a language, or of all languages when lang is empty.
+func (r *Repository) ListSynonyms(ctx context.Context, lang string) ([]Synonym, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT id, lang, term, synonym FROM synonyms WHERE ($1 = '' OR lang = $1) ORDER BY lang, term, id",
+		lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := []Synonym{}
+	for rows.Next() {
+		var s Synonym
+		if err := rows.Scan(&s.ID, &s.Lang, &s.Term, &s.Synonym); err != nil {
+			return nil, err
+		}
+		synonyms = append(synonyms, s)
+	}
+
+	return synonyms, rows.Err()
+}
+
+func (r *Repository) DeleteSynonym(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM synonyms WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSynonymNotFound
+	}
+	return nil
+}
+
+// RecordUnansweredQuery logs a search that found nothing.
+func (r *Repository) RecordUnansweredQuery(ctx context.Context, lang, query string) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO unanswered_queries (lang, query, created_at) VALUES ($1, $2, $3)",
+		lang, query, time.Now().UTC())
+	return err
+}
+
+// ListUnansweredQueries returns the most frequent unanswered queries since the
+// given time, optionally in one language.
+func (r *Repository) ListUnansweredQueries(ctx context.Context, since time.Time, lang string, limit int) ([]UnansweredQuery, error) {
+	rows, err := r.db.Query(ctx, `SELECT MAX(id), lang, query, COUNT(*) FROM unanswered_queries
+        WHERE created_at >= $1 AND ($2 = '' OR lang = $2)
+        GROUP BY lang, query
+        ORDER BY COUNT(*) DESC, MAX(id) DESC
+        LIMIT $3`, since.UTC(), lang, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gaps := []UnansweredQuery{}
+	for rows.Next() {
+		var g UnansweredQuery
+		if err := rows.Scan(&g.ID, &g.Lang, &g.Query, &g.Count); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, g)
+	}
+
+	return gaps, rows.Err()
+}
+
+func (r *Repository) GetUnansweredQuery(ctx context.Context, id int64) (*UnansweredQuery, error) {
+	g := UnansweredQuery{Count: 1}
+	err := r.db.QueryRow(ctx, "SELECT id, lang, query FROM unanswered_queries WHERE id = $1", id).
+		Scan(&g.ID, &g.Lang, &g.Query)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGapNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// ResolveUnansweredQuery forgets a query once a question answering it exists.
+func (r *Repository) ResolveUnansweredQuery(ctx context.Context, lang, query string) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM unanswered_queries WHERE lang = $1 AND query = $2", lang, query)
 	return err
 }
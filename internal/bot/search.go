@@ -0,0 +1,254 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"qaBot/pkg/textsearch"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// maxSearchResults limits the questions offered for one search.
+	maxSearchResults = 10
+
+	// vocabularyTTL is how long the words used for spelling corrections are reused.
+	vocabularyTTL = 10 * time.Minute
+)
+
+// searchResult is the outcome of a search. When nothing matched the query as
+// typed, Questions hold the best guesses: the results for a spelling
+// correction (Suggestion) or, failing that, questions with similar titles.
+type searchResult struct {
+	Questions  []Question
+	Suggestion string
+	Similar    bool
+}
+
+// HandleSearch implements /search <terms>.
+func (b *Bot) HandleSearch(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleSearch received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	query := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/search"))
+	b.search(ctx, tbot, update.Message.Chat.ID, update.Message.From.ID, query)
+}
+
+// search answers a query with an inline keyboard of the matching questions,
+// or of the closest guesses when nothing matches.
+func (b *Bot) search(ctx context.Context, tbot *tgbot.Bot, chatID, userID int64, query string) {
+	lang := b.userLang(ctx, userID)
+
+	if len(textsearch.Words(query)) == 0 {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "search.usage")})
+		return
+	}
+
+	result, err := b.findQuestions(ctx, lang, query)
+	if err != nil {
+		log.Println("failed to search questions: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "search.failed")})
+		return
+	}
+	// Spelling corrections answer the query; mere look-alikes do not
+	if len(result.Questions) == 0 || result.Similar {
+		b.recordUnanswered(ctx, lang, query)
+	}
+
+	var text string
+	switch {
+	case len(result.Questions) == 0:
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "search.none", query)})
+		return
+	case result.Suggestion != "":
+		text = b.t(lang, "search.suggest", query, result.Suggestion)
+	case result.Similar:
+		text = b.t(lang, "search.similar", query)
+	default:
+		text = b.t(lang, "search.results", query)
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for _, q := range result.Questions {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: q.Text, CallbackData: fmt.Sprintf("q_%d", q.ID)},
+		})
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+}
+
+// findQuestions searches in three passes: the query with its synonyms, then
+// the query with misspelled words corrected against the vocabulary of the
+// questions, then a plain similarity ranking of the question titles.
+func (b *Bot) findQuestions(ctx context.Context, lang, query string) (*searchResult, error) {
+	words := textsearch.Words(query)
+	if len(words) == 0 {
+		return &searchResult{}, nil
+	}
+
+	thesaurus := b.thesaurus(ctx, lang)
+
+	questions, err := b.repository.SearchQuestions(ctx, lang, expandQuery(thesaurus, lang, words), maxSearchResults)
+	if err != nil || len(questions) > 0 {
+		return &searchResult{Questions: questions}, err
+	}
+
+	vocab, all, err := b.vocabularies.get(ctx, b.repository, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	if corrected, ok := correctWords(vocab, lang, words); ok {
+		questions, err := b.repository.SearchQuestions(ctx, lang, expandQuery(thesaurus, lang, corrected), maxSearchResults)
+		if err != nil || len(questions) > 0 {
+			return &searchResult{Questions: questions, Suggestion: strings.Join(corrected, " ")}, err
+		}
+	}
+
+	return &searchResult{Questions: similarQuestions(all, words), Similar: true}, nil
+}
+
+// expandQuery adds the synonyms of the words and, for Russian, the Cyrillic
+// spelling of words typed in Latin letters.
+func expandQuery(thesaurus *textsearch.Thesaurus, lang string, words []string) textsearch.Query {
+	query := thesaurus.Expand(words)
+	if lang != "ru" {
+		return query
+	}
+
+	for i, g := range query {
+		if len(g[0]) != 1 {
+			continue
+		}
+		if t := textsearch.Transliterate(g[0][0]); t != g[0][0] {
+			query[i] = append(g, textsearch.Phrase{t})
+		}
+	}
+	return query
+}
+
+// correctWords replaces words missing from the vocabulary by their closest
+// match and drops those without any. It reports whether anything changed.
+func correctWords(vocab *textsearch.Vocabulary, lang string, words []string) ([]string, bool) {
+	var corrected []string
+	changed := false
+
+	for _, w := range words {
+		candidates := []string{w}
+		if lang == "ru" {
+			candidates = append(candidates, textsearch.Transliterate(w))
+		}
+
+		var best textsearch.Suggestion
+		for _, c := range candidates {
+			if vocab.Contains(c) {
+				best = textsearch.Suggestion{Word: c, Score: 1}
+				break
+			}
+			if s := vocab.Closest(c, 1); len(s) > 0 && s[0].Score > best.Score {
+				best = s[0]
+			}
+		}
+
+		if best.Word != w {
+			changed = true
+		}
+		if best.Word != "" {
+			corrected = append(corrected, best.Word)
+		}
+	}
+	return corrected, changed && len(corrected) > 0
+}
+
+// similarQuestions ranks questions by how closely their text resembles the words.
+func similarQuestions(questions []Question, words []string) []Question {
+	type scored struct {
+		q     Question
+		score float64
+	}
+
+	var candidates []scored
+	for _, q := range questions {
+		if score := textsearch.TextSimilarity(words, q.Text); score >= textsearch.MinSimilarity {
+			candidates = append(candidates, scored{q, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	similar := []Question{}
+	for i := 0; i < len(candidates) && i < maxSearchResults; i++ {
+		similar = append(similar, candidates[i].q)
+	}
+	return similar
+}
+
+// thesaurus loads the synonyms of a language. A failure only disables synonyms.
+func (b *Bot) thesaurus(ctx context.Context, lang string) *textsearch.Thesaurus {
+	thesaurus := textsearch.NewThesaurus()
+
+	synonyms, err := b.repository.ListSynonyms(ctx, lang)
+	if err != nil {
+		log.Printf("Failed to load synonyms for %s: %v", lang, err)
+		return thesaurus
+	}
+	for _, s := range synonyms {
+		thesaurus.Add(s.Term, s.Synonym)
+	}
+	return thesaurus
+}
+
+// vocabularyCache keeps the words of the questions per language for
+// spelling corrections. The zero value is ready to use.
+type vocabularyCache struct {
+	mu      sync.Mutex
+	entries map[string]*vocabularyEntry
+}
+
+type vocabularyEntry struct {
+	vocab     *textsearch.Vocabulary
+	questions []Question
+	loadedAt  time.Time
+}
+
+func (c *vocabularyCache) get(ctx context.Context, repo BotRepository, lang string) (*textsearch.Vocabulary, []Question, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[lang]; ok && time.Since(e.loadedAt) < vocabularyTTL {
+		return e.vocab, e.questions, nil
+	}
+
+	questions, err := repo.ListQuestions(ctx, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vocab := textsearch.NewVocabulary()
+	for _, q := range questions {
+		vocab.Add(q.Text)
+		vocab.Add(q.Answer)
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]*vocabularyEntry)
+	}
+	c.entries[lang] = &vocabularyEntry{vocab: vocab, questions: questions, loadedAt: time.Now()}
+	return vocab, questions, nil
+}
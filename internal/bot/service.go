@@ -5,6 +5,11 @@ import (
 	"errors"
 	"log"
 	"sync"
+	"time"
+
+	"qaBot/locales"
+	"qaBot/pkg/i18n"
+	"qaBot/pkg/textsearch"
 
 	tgbot "github.com/go-telegram/bot"
 )
@@ -14,39 +19,123 @@ var (
 	ErrEmptyToken        = errors.New("bot token cannot be empty")
 	ErrBotNotInitialized = errors.New("bot is not initialized")
 	ErrQuestionNotFound  = errors.New("question not found")
+	ErrUnknownRole       = errors.New("unknown role")
+	ErrLastOwner         = errors.New("cannot remove the last owner")
+	ErrLastScope         = errors.New("cannot remove the last scope of an editor")
+	ErrUnknownAction     = errors.New("unknown action")
+	ErrSessionExpired    = errors.New("session expired")
+	ErrRevisionNotFound  = errors.New("revision not found")
+	ErrParentDeleted     = errors.New("parent question is deleted")
+	ErrSynonymNotFound   = errors.New("synonym not found")
+	ErrGapNotFound       = errors.New("unanswered query not found")
+	ErrQuestionCycle     = errors.New("question cannot be moved under itself")
+	ErrVersionConflict   = errors.New("question was changed by someone else")
 )
 
 type BotRepository interface {
 	GetQuestionsByLang(ctx context.Context, lang string) ([]Question, error)
+	GetQuestionLanguages(ctx context.Context) ([]string, error)
 	GetSubQuestions(ctx context.Context, parentID int) ([]Question, error)
 	GetQuestionByID(ctx context.Context, id int) (*Question, error)
+	GetQuestionShallow(ctx context.Context, id int) (*Question, error)
 	SetUserLang(ctx context.Context, userID int64, lang string) error
 	GetUserLang(ctx context.Context, userID int64) (string, error)
-	CreateQuestion(ctx context.Context, lang, text, answer string, parentID int) (int, error)
+	CreateQuestion(ctx context.Context, lang, text, answer, fileType, fileID string, parentID int) (int, error)
 	UpdateQuestion(ctx context.Context, id int, text, answer string) error
-	DeleteQuestionByID(ctx context.Context, id int) error
+	DeleteQuestionByID(ctx context.Context, id int, deletedBy int64) error
 	UpdateQuestionFile(ctx context.Context, id int, fileType, fileID string) error
+	CheckQuestionVersion(ctx context.Context, id, version int) error
+	SwapQuestions(ctx context.Context, id, otherID int) error
+	MoveQuestion(ctx context.Context, id, parentID int) error
+
+	GetAdminRole(ctx context.Context, userID int64) (Role, error)
+	SetAdminRole(ctx context.Context, userID int64, role Role) error
+	RemoveAdmin(ctx context.Context, userID int64) error
+	ListAdmins(ctx context.Context) ([]Admin, error)
+	AddAdminScope(ctx context.Context, scope Scope) (int, error)
+	ListAdminScopes(ctx context.Context, userID int64) ([]Scope, error)
+	DeleteAdminScope(ctx context.Context, id int) error
+	GetQuestionPath(ctx context.Context, id int) ([]int, error)
+	GetDescendants(ctx context.Context, id int) ([]Question, error)
+
+	AddRevision(ctx context.Context, rev Revision) (int, error)
+	ListRevisions(ctx context.Context, questionID int) ([]Revision, error)
+	GetRevision(ctx context.Context, id int) (*Revision, error)
+
+	ListDeletedQuestions(ctx context.Context) ([]DeletedQuestion, error)
+	RestoreQuestion(ctx context.Context, id int) error
+	PurgeDeletedQuestions(ctx context.Context, before time.Time) (int, error)
+	SearchQuestions(ctx context.Context, lang string, query textsearch.Query, limit int) ([]Question, error)
+	ListQuestions(ctx context.Context, lang string) ([]Question, error)
+
+	GetTranslations(ctx context.Context, id int) ([]Question, error)
+	LinkTranslations(ctx context.Context, id, otherID int) error
+	UnlinkTranslation(ctx context.Context, id int) error
+
+	AddSynonym(ctx context.Context, s Synonym) (int, error)
+	ListSynonyms(ctx context.Context, lang string) ([]Synonym, error)
+	DeleteSynonym(ctx context.Context, id int) error
+
+	RecordUnansweredQuery(ctx context.Context, lang, query string) error
+	ListUnansweredQueries(ctx context.Context, since time.Time, lang string, limit int) ([]UnansweredQuery, error)
+	GetUnansweredQuery(ctx context.Context, id int64) (*UnansweredQuery, error)
+	ResolveUnansweredQuery(ctx context.Context, lang, query string) error
+
+	AddAuditEntry(ctx context.Context, entry AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+
+	// WithTx runs fn with a repository whose statements share one
+	// transaction. It commits when fn returns nil and rolls back otherwise,
+	// returning fn's error. WithTx called inside fn joins the transaction.
+	WithTx(ctx context.Context, fn func(tx BotRepository) error) error
 }
 
 type Bot struct {
 	api        *tgbot.Bot
 	repository BotRepository
+	auth       *AuthService
+	sessions   SessionStore
+	catalog    *i18n.Catalog
 
-	pendingQuestionEdits map[int64]*PendingQuestionData
-	pendingMutex         sync.RWMutex
+	vocabularies     vocabularyCache
+	knownUsers       sync.Map // user ID -> struct{}, users whose language is stored
+	username         botUsername
+	linkSecret       []byte
+	acceptPlainLinks bool // plain q_<id> links still open with a link secret
 }
 
-// NewBot initializes a new Bot instance with the provided token and database.
-func NewBot(token string, repo BotRepository, workers int) (*Bot, error) {
+// NewBot initializes a new Bot instance with the provided token, database,
+// session store and message catalog. A nil store keeps sessions in memory; a
+// nil catalog speaks the languages of the embedded locales.
+func NewBot(token string, repo BotRepository, sessions SessionStore, catalog *i18n.Catalog, workers int) (*Bot, error) {
 	if token == "" {
 		return nil, ErrEmptyToken
 	}
 
 	log.Println("Initializing bot with provided token...")
 
+	if sessions == nil {
+		sessions = NewMemorySessionStore(DefaultSessionTTL)
+	}
+
+	if catalog == nil {
+		var err error
+		if catalog, err = i18n.Load(locales.FS, nil, "en"); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &Bot{
+		repository: repo,
+		auth:       NewAuthService(repo),
+		sessions:   sessions,
+		catalog:    catalog,
+	}
+
 	bot, err := tgbot.New(
 		token,
 		tgbot.WithWorkers(workers),
+		tgbot.WithMiddlewares(b.detectLanguage),
 	)
 	if err != nil {
 		log.Printf("Failed to create new bot: %v\n", err)
@@ -54,11 +143,28 @@ func NewBot(token string, repo BotRepository, workers int) (*Bot, error) {
 	}
 	log.Println("Telegram bot initialized successfully")
 
-	return &Bot{
-		api:                  bot,
-		repository:           repo,
-		pendingQuestionEdits: make(map[int64]*PendingQuestionData),
-	}, nil
+	b.api = bot
+	return b, nil
+}
+
+// EnsureOwners grants the owner role to the configured bootstrap owners.
+func (b *Bot) EnsureOwners(ctx context.Context, userIDs []int64) error {
+	previous := make(map[int64]Role, len(userIDs))
+	for _, id := range userIDs {
+		previous[id] = b.auth.Role(ctx, id)
+	}
+
+	if err := b.auth.EnsureOwners(ctx, userIDs); err != nil {
+		return err
+	}
+
+	// Grants from the config file are recorded with actor 0
+	for id, role := range previous {
+		if role != RoleOwner {
+			b.recordAudit(ctx, AuditEntry{Action: AuditRoleSet, TargetUserID: id}, roleChange{Role: role}, roleChange{Role: RoleOwner})
+		}
+	}
+	return nil
 }
 
 // Start begins listening for updates and initializes questions from the database.
@@ -79,8 +185,8 @@ func (b *Bot) Start(ctx context.Context) error {
 
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeMessageText,
-		"/start",
-		tgbot.MatchTypeExact,
+		"start",
+		tgbot.MatchTypeCommandStartOnly,
 		b.GetStart,
 	)
 
@@ -91,6 +197,20 @@ func (b *Bot) Start(ctx context.Context) error {
 		b.HandleLanguage,
 	)
 
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/cancel",
+		tgbot.MatchTypeExact,
+		b.HandleCancel,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/admin",
+		tgbot.MatchTypePrefix,
+		b.HandleAdminCommand,
+	)
+
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeCallbackQueryData,
 		"q_",
@@ -98,6 +218,20 @@ func (b *Bot) Start(ctx context.Context) error {
 		b.HandleQuestionCallback,
 	)
 
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"share_",
+		tgbot.MatchTypePrefix,
+		b.HandleShareCallback,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"tr_",
+		tgbot.MatchTypePrefix,
+		b.HandleTranslationsCallback,
+	)
+
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeCallbackQueryData,
 		"p_",
@@ -133,20 +267,185 @@ func (b *Bot) Start(ctx context.Context) error {
 		b.HandleDeleteQuestion,
 	)
 
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"delok_",
+		tgbot.MatchTypePrefix,
+		b.HandleDeleteConfirm,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"delno_",
+		tgbot.MatchTypePrefix,
+		b.HandleDeleteCancel,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/search",
+		tgbot.MatchTypePrefix,
+		b.HandleSearch,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/synonyms",
+		tgbot.MatchTypePrefix,
+		b.HandleSynonymsCommand,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/translations",
+		tgbot.MatchTypePrefix,
+		b.HandleTranslationsCommand,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/gaps",
+		tgbot.MatchTypePrefix,
+		b.HandleGaps,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"gap_",
+		tgbot.MatchTypePrefix,
+		b.HandleGapCallback,
+	)
+
+	b.api.RegisterHandlerMatchFunc(isInlineQuery, b.HandleInlineQuery)
+
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeMessageText,
-		"English",
+		"/audit",
+		tgbot.MatchTypePrefix,
+		b.HandleAuditCommand,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/export",
+		tgbot.MatchTypePrefix,
+		b.HandleExportCommand,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeMessageText,
+		"/trash",
 		tgbot.MatchTypeExact,
-		b.HandleLanguageSelection,
+		b.HandleTrash,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"trrest_",
+		tgbot.MatchTypePrefix,
+		b.HandleTrashRestore,
 	)
 
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeMessageText,
-		"Русский",
+		"/diff",
+		tgbot.MatchTypePrefix,
+		b.HandleDiffCommand,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"hist_",
+		tgbot.MatchTypePrefix,
+		b.HandleHistory,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"rev_",
+		tgbot.MatchTypePrefix,
+		b.HandleRevision,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"rdiff_",
+		tgbot.MatchTypePrefix,
+		b.HandleRevisionDiff,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"rrest_",
+		tgbot.MatchTypePrefix,
+		b.HandleRevisionRestore,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"wiz_",
+		tgbot.MatchTypePrefix,
+		b.HandleWizardCallback,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"up_",
+		tgbot.MatchTypePrefix,
+		b.HandleReorderCallback,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"down_",
+		tgbot.MatchTypePrefix,
+		b.HandleReorderCallback,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"mv_",
+		tgbot.MatchTypePrefix,
+		b.HandleMoveCallback,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"mvnav_",
+		tgbot.MatchTypePrefix,
+		b.HandleMoveNavigate,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"mvto_",
+		tgbot.MatchTypePrefix,
+		b.HandleMoveConfirm,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"mvno_",
+		tgbot.MatchTypePrefix,
+		b.HandleMoveCancel,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"impok_",
+		tgbot.MatchTypePrefix,
+		b.HandleImportConfirm,
+	)
+
+	b.api.RegisterHandler(
+		tgbot.HandlerTypeCallbackQueryData,
+		"impno",
 		tgbot.MatchTypeExact,
-		b.HandleLanguageSelection,
+		b.HandleImportCancel,
 	)
 
+	b.api.RegisterHandlerMatchFunc(b.isLanguageSelection, b.HandleLanguageSelection)
+
 	b.api.RegisterHandler(
 		tgbot.HandlerTypeMessageText,
 		"",
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const DefaultSessionTTL = 30 * time.Minute
+
+// SessionStore keeps the admins' pending add/edit sessions.
+//
+// Get returns (nil, nil) when the user has no session. A session whose TTL has
+// passed is removed and returned together with ErrSessionExpired, so the
+// caller can tell the admin what was discarded.
+type SessionStore interface {
+	Get(ctx context.Context, userID int64) (*PendingQuestionData, error)
+	Save(ctx context.Context, userID int64, data *PendingQuestionData) error
+	Delete(ctx context.Context, userID int64) error
+}
+
+// MemorySessionStore keeps sessions in process memory; they are lost on restart.
+type MemorySessionStore struct {
+	ttl      time.Duration
+	mu       sync.RWMutex
+	sessions map[int64]*PendingQuestionData
+}
+
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	return &MemorySessionStore{
+		ttl:      ttl,
+		sessions: make(map[int64]*PendingQuestionData),
+	}
+}
+
+func (s *MemorySessionStore) Get(ctx context.Context, userID int64) (*PendingQuestionData, error) {
+	s.mu.RLock()
+	data, ok := s.sessions[userID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	if data.expired() {
+		s.Delete(ctx, userID)
+		return data, ErrSessionExpired
+	}
+	return data, nil
+}
+
+func (s *MemorySessionStore) Save(_ context.Context, userID int64, data *PendingQuestionData) error {
+	data.ExpiresAt = time.Now().Add(s.ttl)
+
+	s.mu.Lock()
+	s.sessions[userID] = data
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	delete(s.sessions, userID)
+	s.mu.Unlock()
+	return nil
+}
+
+// PostgresSessionStore keeps sessions in the admin_sessions table.
+type PostgresSessionStore struct {
+	db  *pgxpool.Pool
+	ttl time.Duration
+}
+
+func NewPostgresSessionStore(db *pgxpool.Pool, ttl time.Duration) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db, ttl: ttl}
+}
+
+func (s *PostgresSessionStore) Get(ctx context.Context, userID int64) (*PendingQuestionData, error) {
+	var raw string
+	err := s.db.QueryRow(ctx, "SELECT data FROM admin_sessions WHERE user_id = $1", userID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSession(ctx, s, userID, raw)
+}
+
+func (s *PostgresSessionStore) Save(ctx context.Context, userID int64, data *PendingQuestionData) error {
+	data.ExpiresAt = time.Now().Add(s.ttl)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(ctx,
+		`INSERT INTO admin_sessions (user_id, data, expires_at) VALUES ($1, $2, $3)
+        ON CONFLICT(user_id) DO UPDATE SET data=excluded.data, expires_at=excluded.expires_at`,
+		userID, string(raw), data.ExpiresAt)
+	return err
+}
+
+func (s *PostgresSessionStore) Delete(ctx context.Context, userID int64) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM admin_sessions WHERE user_id = $1", userID)
+	return err
+}
+
+// SQLiteSessionStore keeps sessions in the admin_sessions table.
+type SQLiteSessionStore struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func NewSQLiteSessionStore(db *sql.DB, ttl time.Duration) *SQLiteSessionStore {
+	return &SQLiteSessionStore{db: db, ttl: ttl}
+}
+
+func (s *SQLiteSessionStore) Get(ctx context.Context, userID int64) (*PendingQuestionData, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, "SELECT data FROM admin_sessions WHERE user_id = ?", userID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSession(ctx, s, userID, raw)
+}
+
+func (s *SQLiteSessionStore) Save(ctx context.Context, userID int64, data *PendingQuestionData) error {
+	data.ExpiresAt = time.Now().Add(s.ttl)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO admin_sessions (user_id, data, expires_at) VALUES (?, ?, ?)
+        ON CONFLICT(user_id) DO UPDATE SET data=excluded.data, expires_at=excluded.expires_at`,
+		userID, string(raw), data.ExpiresAt)
+	return err
+}
+
+func (s *SQLiteSessionStore) Delete(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM admin_sessions WHERE user_id = ?", userID)
+	return err
+}
+
+// decodeSession unmarshals a stored session and applies TTL expiry.
+func decodeSession(ctx context.Context, store SessionStore, userID int64, raw string) (*PendingQuestionData, error) {
+	var data PendingQuestionData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+
+	if data.expired() {
+		if err := store.Delete(ctx, userID); err != nil {
+			return nil, err
+		}
+		return &data, ErrSessionExpired
+	}
+	return &data, nil
+}
@@ -0,0 +1,840 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"qaBot/pkg/textsearch"
+)
+
+// SQLiteRepository implements BotRepository on top of a database/sql SQLite handle.
+type SQLiteRepository struct {
+	db sqlQuerier
+}
+
+// sqlQuerier is what the repository runs its statements on: the database, or
+// a transaction inside WithTx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+func (r *SQLiteRepository) WithTx(ctx context.Context, fn func(tx BotRepository) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteRepository{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetQuestionsByLang returns the top-level questions of a language with
+// their whole subtrees, loaded in a single query.
+func (r *SQLiteRepository) GetQuestionsByLang(ctx context.Context, lang string) ([]Question, error) {
+	return r.getQuestionTree(ctx, "lang = ? AND parent_id IS NULL", lang)
+}
+
+// GetQuestionLanguages returns the languages that have top-level questions,
+// in alphabetical order.
+func (r *SQLiteRepository) GetQuestionLanguages(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT lang FROM questions
+        WHERE parent_id IS NULL AND deleted_at IS NULL ORDER BY lang`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	langs := []string{}
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, err
+		}
+		langs = append(langs, lang)
+	}
+	return langs, rows.Err()
+}
+
+// getQuestionTree loads the questions matching rootCondition with all their
+// descendants, see questionTreeQuery.
+func (r *SQLiteRepository) getQuestionTree(ctx context.Context, rootCondition string, args ...any) ([]Question, error) {
+	rows, err := r.db.QueryContext(ctx, questionTreeQuery(rootCondition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group, &q.Version); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildQuestionTree(questions), nil
+}
+
+func (r *SQLiteRepository) GetSubQuestions(ctx context.Context, parentID int) ([]Question, error) {
+	subQuestions := []Question{}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, lang, text, answer, file_type, file_id, parent_id FROM questions WHERE parent_id = ? AND deleted_at IS NULL ORDER BY position, id", parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q Question
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &q.ParentID); err != nil {
+			log.Printf("Failed to scan subquestion: %v", err)
+			continue
+		}
+		subQuestions = append(subQuestions, q)
+	}
+
+	return subQuestions, rows.Err()
+}
+
+// SetUserLang saves or updates the user's language preference.
+func (r *SQLiteRepository) SetUserLang(ctx context.Context, userID int64, lang string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_languages (user_id, lang) VALUES (?, ?)
+        ON CONFLICT(user_id) DO UPDATE SET lang=excluded.lang`,
+		userID, lang)
+	return err
+}
+
+// GetUserLang retrieves the user's language preference, or returns "" if not set.
+func (r *SQLiteRepository) GetUserLang(ctx context.Context, userID int64) (string, error) {
+	var lang string
+	err := r.db.QueryRowContext(ctx, "SELECT lang FROM user_languages WHERE user_id = ?", userID).Scan(&lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return lang, err
+}
+
+// GetQuestionByID retrieves a question with its whole subtree by its ID.
+func (r *SQLiteRepository) GetQuestionByID(ctx context.Context, id int) (*Question, error) {
+	tree, err := r.getQuestionTree(ctx, "id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &tree[0], nil
+}
+
+// GetQuestionShallow returns a question without its sub-questions, for
+// callers that only need the question itself.
+func (r *SQLiteRepository) GetQuestionShallow(ctx context.Context, id int) (*Question, error) {
+	var (
+		q        Question
+		parentID sql.NullInt32
+		group    sql.NullInt32
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, lang, text, answer, file_type, file_id, parent_id, translation_group, version
+        FROM questions WHERE id = ? AND deleted_at IS NULL`, id).
+		Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group, &q.Version)
+	if err != nil {
+		return nil, err
+	}
+	q.ParentID = int(parentID.Int32)
+	q.TranslationGroup = int(group.Int32)
+	return &q, nil
+}
+
+// DeleteQuestionByID soft-deletes a question together with its subtree. The
+// rows keep the ID of the deleted root so the subtree can be restored as a whole.
+func (r *SQLiteRepository) DeleteQuestionByID(ctx context.Context, id int, deletedBy int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE questions SET deleted_at = ?2, deleted_by = ?3, deleted_root_id = ?1
+        WHERE id IN (
+            WITH RECURSIVE subtree(id) AS (
+                SELECT id FROM questions WHERE id = ?1 AND deleted_at IS NULL
+                UNION ALL
+                SELECT q.id FROM questions q JOIN subtree s ON q.parent_id = s.id WHERE q.deleted_at IS NULL
+            )
+            SELECT id FROM subtree
+        )`, id, time.Now().UTC(), deletedBy)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrQuestionNotFound
+	}
+
+	return nil
+}
+
+// CreateQuestion inserts a new question, with its attachment if any, into the
+// questions table.
+func (r *SQLiteRepository) CreateQuestion(ctx context.Context, lang, text, answer, fileType, fileID string, parentID int) (int, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO questions (lang, text, answer, file_type, file_id, parent_id, position)
+        VALUES (?1, ?2, ?3, ?4, ?5, ?6, (
+            SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE parent_id IS ?6 AND lang = ?1
+        ))`,
+		lang, text, answer, fileType, fileID, sql.NullInt32{Int32: int32(parentID), Valid: parentID != 0},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// SwapQuestions exchanges the positions of two questions, which should be
+// siblings.
+func (r *SQLiteRepository) SwapQuestions(ctx context.Context, id, otherID int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE questions
+        SET position = (SELECT SUM(position) FROM questions WHERE id IN (?1, ?2)) - position
+        WHERE id IN (?1, ?2)`, id, otherID)
+	return err
+}
+
+// MoveQuestion puts a question, with its subtree, last under a new parent; 0
+// moves it to the top level. A question cannot be moved under itself or one
+// of its descendants, nor under a deleted question.
+func (r *SQLiteRepository) MoveQuestion(ctx context.Context, id, parentID int) error {
+	if parentID != 0 {
+		var parentDeleted bool
+		err := r.db.QueryRowContext(ctx, "SELECT deleted_at IS NOT NULL FROM questions WHERE id = ?", parentID).Scan(&parentDeleted)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrQuestionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if parentDeleted {
+			return ErrParentDeleted
+		}
+
+		path, err := r.GetQuestionPath(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(path, id) {
+			return ErrQuestionCycle
+		}
+	}
+
+	res, err := r.db.ExecContext(ctx, `UPDATE questions SET parent_id = ?2, position = (
+            SELECT COALESCE(MAX(s.position), 0) + 1 FROM questions s WHERE s.parent_id IS ?2 AND s.lang = questions.lang
+        )
+        WHERE id = ?1 AND deleted_at IS NULL
+            AND (?2 IS NULL OR EXISTS (SELECT 1 FROM questions p WHERE p.id = ?2 AND p.deleted_at IS NULL))`, id, sql.NullInt32{Int32: int32(parentID), Valid: parentID != 0})
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrQuestionNotFound
+	}
+	return nil
+}
+
+// UpdateQuestion updates the text and answer of a question by its ID.
+func (r *SQLiteRepository) UpdateQuestion(ctx context.Context, id int, text, answer string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE questions SET text = ?1, answer = ?2,
+            version = version + CASE WHEN text <> ?1 OR answer <> ?2 THEN 1 ELSE 0 END
+        WHERE id = ?3`,
+		text, answer, id,
+	)
+	return err
+}
+
+// UpdateQuestionFile updates the file type and file_id of a question by its ID.
+func (r *SQLiteRepository) UpdateQuestionFile(ctx context.Context, id int, fileType, fileID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE questions SET file_type = ?1, file_id = ?2,
+            version = version + CASE WHEN file_type <> ?1 OR file_id <> ?2 THEN 1 ELSE 0 END
+        WHERE id = ?3`, fileType, fileID, id)
+	return err
+}
+
+// CheckQuestionVersion returns ErrVersionConflict unless a question is still
+// at the given version. Transactions hold the database write lock from the
+// start, so the question cannot change before they end.
+func (r *SQLiteRepository) CheckQuestionVersion(ctx context.Context, id, version int) error {
+	var current int
+	err := r.db.QueryRowContext(ctx, "SELECT version FROM questions WHERE id = ? AND deleted_at IS NULL", id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// GetAdminRole returns the role assigned to the user, or "" if the user is not an admin.
+func (r *SQLiteRepository) GetAdminRole(ctx context.Context, userID int64) (Role, error) {
+	var role Role
+	err := r.db.QueryRowContext(ctx, "SELECT role FROM admins WHERE user_id = ?", userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// SetAdminRole grants a role to the user, replacing any previous one.
+func (r *SQLiteRepository) SetAdminRole(ctx context.Context, userID int64, role Role) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO admins (user_id, role) VALUES (?, ?)
+        ON CONFLICT(user_id) DO UPDATE SET role=excluded.role`,
+		userID, role)
+	return err
+}
+
+// RemoveAdmin revokes all admin rights of the user.
+func (r *SQLiteRepository) RemoveAdmin(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM admins WHERE user_id = ?", userID)
+	return err
+}
+
+// ListAdmins returns all admins ordered by the time they were added.
+func (r *SQLiteRepository) ListAdmins(ctx context.Context) ([]Admin, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT user_id, role, created_at FROM admins ORDER BY created_at, user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	admins := []Admin{}
+	for rows.Next() {
+		var a Admin
+		if err := rows.Scan(&a.UserID, &a.Role, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		admins = append(admins, a)
+	}
+
+	return admins, rows.Err()
+}
+
+// AddAdminScope restricts an admin's rights to a language and/or subtree.
+func (r *SQLiteRepository) AddAdminScope(ctx context.Context, scope Scope) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO admin_scopes (user_id, lang, root_id, actions) VALUES (?, ?, ?, ?)",
+		scope.UserID, scope.Lang, scope.RootID, joinActions(scope.Actions),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// ListAdminScopes returns the scopes of one admin, or of every admin when userID is 0.
+func (r *SQLiteRepository) ListAdminScopes(ctx context.Context, userID int64) ([]Scope, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, user_id, lang, root_id, actions FROM admin_scopes WHERE ?1 = 0 OR user_id = ?1 ORDER BY user_id, id",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scopes := []Scope{}
+	for rows.Next() {
+		var (
+			s       Scope
+			actions string
+		)
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Lang, &s.RootID, &actions); err != nil {
+			return nil, err
+		}
+		s.Actions = splitActions(actions)
+		scopes = append(scopes, s)
+	}
+
+	return scopes, rows.Err()
+}
+
+// DeleteAdminScope removes a single scope by its ID.
+func (r *SQLiteRepository) DeleteAdminScope(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM admin_scopes WHERE id = ?", id)
+	return err
+}
+
+// GetQuestionPath returns the question ID followed by the IDs of all its ancestors.
+func (r *SQLiteRepository) GetQuestionPath(ctx context.Context, id int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `WITH RECURSIVE path(id, parent_id, depth) AS (
+            SELECT id, parent_id, 0 FROM questions WHERE id = ?
+            UNION ALL
+            SELECT q.id, q.parent_id, p.depth + 1 FROM questions q JOIN path p ON q.id = p.parent_id
+        )
+        SELECT id FROM path ORDER BY depth`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	path := []int{}
+	for rows.Next() {
+		var qID int
+		if err := rows.Scan(&qID); err != nil {
+			return nil, err
+		}
+		path = append(path, qID)
+	}
+
+	return path, rows.Err()
+}
+
+// GetDescendants returns every non-deleted question below the given one, breadth first.
+func (r *SQLiteRepository) GetDescendants(ctx context.Context, id int) ([]Question, error) {
+	rows, err := r.db.QueryContext(ctx, `WITH RECURSIVE subtree(id, depth) AS (
+            SELECT id, 1 FROM questions WHERE parent_id = ? AND deleted_at IS NULL
+            UNION ALL
+            SELECT q.id, s.depth + 1 FROM questions q JOIN subtree s ON q.parent_id = s.id WHERE q.deleted_at IS NULL
+        )
+        SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id
+        FROM questions q JOIN subtree s ON q.id = s.id
+        ORDER BY s.depth, q.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	descendants := []Question{}
+	for rows.Next() {
+		var q Question
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &q.ParentID); err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, q)
+	}
+
+	return descendants, rows.Err()
+}
+
+// AddRevision stores a snapshot of a question after a change.
+func (r *SQLiteRepository) AddRevision(ctx context.Context, rev Revision) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO question_revisions (question_id, action, text, answer, file_type, file_id, author_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rev.QuestionID, rev.Action, rev.Text, rev.Answer, rev.FileType, rev.FileID, rev.AuthorID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// ListRevisions returns the revisions of a question, newest first.
+func (r *SQLiteRepository) ListRevisions(ctx context.Context, questionID int) ([]Revision, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, question_id, action, text, answer, file_type, file_id, author_id, created_at FROM question_revisions WHERE question_id = ? ORDER BY id DESC",
+		questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var rev Revision
+		if err := rows.Scan(&rev.ID, &rev.QuestionID, &rev.Action, &rev.Text, &rev.Answer, &rev.FileType, &rev.FileID, &rev.AuthorID, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieves a single revision by its ID.
+func (r *SQLiteRepository) GetRevision(ctx context.Context, id int) (*Revision, error) {
+	var rev Revision
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, question_id, action, text, answer, file_type, file_id, author_id, created_at FROM question_revisions WHERE id = ?",
+		id).Scan(&rev.ID, &rev.QuestionID, &rev.Action, &rev.Text, &rev.Answer, &rev.FileType, &rev.FileID, &rev.AuthorID, &rev.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// ListDeletedQuestions returns the roots of deleted subtrees, most recently deleted first.
+func (r *SQLiteRepository) ListDeletedQuestions(ctx context.Context) ([]DeletedQuestion, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id,
+            q.deleted_at, q.deleted_by,
+            (SELECT COUNT(*) FROM questions d WHERE d.deleted_root_id = q.id AND d.id <> q.id)
+        FROM questions q
+        WHERE q.deleted_root_id = q.id
+        ORDER BY q.deleted_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := []DeletedQuestion{}
+	for rows.Next() {
+		var (
+			d        DeletedQuestion
+			parentID sql.NullInt32
+		)
+		if err := rows.Scan(&d.ID, &d.Lang, &d.Text, &d.Answer, &d.FileType, &d.FileID, &parentID,
+			&d.DeletedAt, &d.DeletedBy, &d.Descendants); err != nil {
+			return nil, err
+		}
+		d.ParentID = int(parentID.Int32)
+		deleted = append(deleted, d)
+	}
+
+	return deleted, rows.Err()
+}
+
+// RestoreQuestion restores a deleted subtree under its original parent.
+func (r *SQLiteRepository) RestoreQuestion(ctx context.Context, id int) error {
+	var parentDeleted bool
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(p.deleted_at IS NOT NULL, 0)
+        FROM questions q LEFT JOIN questions p ON p.id = q.parent_id
+        WHERE q.id = ? AND q.deleted_root_id = q.id`, id).Scan(&parentDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if parentDeleted {
+		return ErrParentDeleted
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"UPDATE questions SET deleted_at = NULL, deleted_by = NULL, deleted_root_id = NULL WHERE deleted_root_id = ?",
+		id)
+	return err
+}
+
+// PurgeDeletedQuestions hard-deletes the subtrees deleted before the given
+// time. The parent_id foreign key does not cascade in SQLite, so everything
+// below a purged root goes in the same statement.
+func (r *SQLiteRepository) PurgeDeletedQuestions(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM questions WHERE id IN (
+            WITH RECURSIVE subtree(id) AS (
+                SELECT id FROM questions WHERE deleted_root_id = id AND deleted_at < ?
+                UNION
+                SELECT q.id FROM questions q JOIN subtree s ON q.parent_id = s.id
+            )
+            SELECT id FROM subtree
+        )`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// AddAuditEntry appends an entry to the audit log.
+func (r *SQLiteRepository) AddAuditEntry(ctx context.Context, entry AuditEntry) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO audit_log (actor_id, action, question_id, target_user_id, payload_before, payload_after, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.ActorID, entry.Action, nullInt(int64(entry.QuestionID)), nullInt(entry.TargetUserID),
+		entry.Before, entry.After, time.Now().UTC())
+	return err
+}
+
+// ListAuditEntries returns audit entries matching the filter, newest first.
+func (r *SQLiteRepository) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	where, args := auditConditions(filter, func(int) string { return "?" })
+	query := "SELECT id, actor_id, action, question_id, target_user_id, payload_before, payload_after, created_at FROM audit_log" +
+		where + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var (
+			e            AuditEntry
+			questionID   sql.NullInt32
+			targetUserID sql.NullInt64
+		)
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &questionID, &targetUserID, &e.Before, &e.After, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.QuestionID = int(questionID.Int32)
+		e.TargetUserID = targetUserID.Int64
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// SearchQuestions runs a full-text search over the text and answer of the
+// questions in one language, best matches first.
+func (r *SQLiteRepository) SearchQuestions(ctx context.Context, lang string, query textsearch.Query, limit int) ([]Question, error) {
+	if len(query) == 0 {
+		return []Question{}, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id
+        FROM questions_fts f JOIN questions q ON q.id = f.rowid
+        WHERE questions_fts MATCH ? AND q.lang = ? AND q.deleted_at IS NULL
+        ORDER BY bm25(questions_fts, 10.0, 1.0), q.id
+        LIMIT ?`, query.FTS5(), lang, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// ListQuestions returns every question in a language, or in all languages
+// when lang is empty, without their sub-questions.
+func (r *SQLiteRepository) ListQuestions(ctx context.Context, lang string) ([]Question, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, lang, text, answer, file_type, file_id, parent_id, translation_group FROM questions
+        WHERE (?1 = '' OR lang = ?1) AND deleted_at IS NULL ORDER BY id`, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// GetTranslations returns the other questions in the translation group of a
+// question, without their sub-questions.
+func (r *SQLiteRepository) GetTranslations(ctx context.Context, id int) ([]Question, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT t.id, t.lang, t.text, t.answer, t.file_type, t.file_id, t.parent_id, t.translation_group
+        FROM questions q JOIN questions t ON t.translation_group = q.translation_group
+        WHERE q.id = ?1 AND t.id <> q.id AND t.deleted_at IS NULL
+        ORDER BY t.lang, t.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var (
+			q        Question
+			parentID sql.NullInt32
+			group    sql.NullInt32
+		)
+		if err := rows.Scan(&q.ID, &q.Lang, &q.Text, &q.Answer, &q.FileType, &q.FileID, &parentID, &group); err != nil {
+			return nil, err
+		}
+		q.ParentID = int(parentID.Int32)
+		q.TranslationGroup = int(group.Int32)
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// LinkTranslations puts two questions, together with the translations each
+// already has, into one translation group.
+func (r *SQLiteRepository) LinkTranslations(ctx context.Context, id, otherID int) error {
+	_, err := r.db.ExecContext(ctx, `WITH target AS (
+            SELECT COALESCE(
+                (SELECT translation_group FROM questions WHERE id = ?2),
+                (SELECT translation_group FROM questions WHERE id = ?1),
+                (SELECT COALESCE(MAX(translation_group), 0) + 1 FROM questions)
+            ) AS grp
+        )
+        UPDATE questions SET translation_group = (SELECT grp FROM target)
+        WHERE id IN (?1, ?2) OR translation_group IN (
+            SELECT translation_group FROM questions WHERE id IN (?1, ?2) AND translation_group IS NOT NULL
+        )`, id, otherID)
+	return err
+}
+
+// UnlinkTranslation removes a question from its translation group. A group
+// left with a single question is dissolved.
+func (r *SQLiteRepository) UnlinkTranslation(ctx context.Context, id int) error {
+	if _, err := r.db.ExecContext(ctx, "UPDATE questions SET translation_group = NULL WHERE id = ?1", id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE questions SET translation_group = NULL WHERE translation_group IN (
+            SELECT translation_group FROM questions WHERE translation_group IS NOT NULL
+            GROUP BY translation_group HAVING COUNT(*) = 1
+        )`)
+	return err
+}
+
+func (r *SQLiteRepository) AddSynonym(ctx context.Context, s Synonym) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO synonyms (lang, term, synonym) VALUES (?, ?, ?)",
+		s.Lang, s.Term, s.Synonym)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// ListSynonyms returns the synonyms of a language, or of all languages when lang is empty.
+func (r *SQLiteRepository) ListSynonyms(ctx context.Context, lang string) ([]Synonym, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, lang, term, synonym FROM synonyms WHERE (?1 = '' OR lang = ?1) ORDER BY lang, term, id",
+		lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := []Synonym{}
+	for rows.Next() {
+		var s Synonym
+		if err := rows.Scan(&s.ID, &s.Lang, &s.Term, &s.Synonym); err != nil {
+			return nil, err
+		}
+		synonyms = append(synonyms, s)
+	}
+
+	return synonyms, rows.Err()
+}
+
+func (r *SQLiteRepository) DeleteSynonym(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM synonyms WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrSynonymNotFound
+	}
+	return nil
+}
+
+// RecordUnansweredQuery logs a search that found nothing.
+func (r *SQLiteRepository) RecordUnansweredQuery(ctx context.Context, lang, query string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO unanswered_queries (lang, query, created_at) VALUES (?, ?, ?)",
+		lang, query, time.Now().UTC())
+	return err
+}
+
+// ListUnansweredQueries returns the most frequent unanswered queries since the
+// given time, optionally in one language.
+func (r *SQLiteRepository) ListUnansweredQueries(ctx context.Context, since time.Time, lang string, limit int) ([]UnansweredQuery, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT MAX(id), lang, query, COUNT(*) FROM unanswered_queries
+        WHERE created_at >= ?1 AND (?2 = '' OR lang = ?2)
+        GROUP BY lang, query
+        ORDER BY COUNT(*) DESC, MAX(id) DESC
+        LIMIT ?3`, since.UTC(), lang, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gaps := []UnansweredQuery{}
+	for rows.Next() {
+		var g UnansweredQuery
+		if err := rows.Scan(&g.ID, &g.Lang, &g.Query, &g.Count); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, g)
+	}
+
+	return gaps, rows.Err()
+}
+
+func (r *SQLiteRepository) GetUnansweredQuery(ctx context.Context, id int64) (*UnansweredQuery, error) {
+	g := UnansweredQuery{Count: 1}
+	err := r.db.QueryRowContext(ctx, "SELECT id, lang, query FROM unanswered_queries WHERE id = ?", id).
+		Scan(&g.ID, &g.Lang, &g.Query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGapNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// ResolveUnansweredQuery forgets a query once a question answering it exists.
+func (r *SQLiteRepository) ResolveUnansweredQuery(ctx context.Context, lang, query string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM unanswered_queries WHERE lang = ? AND query = ?", lang, query)
+	return err
+}
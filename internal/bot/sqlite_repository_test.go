@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"qaBot/internal/infrastructure/database"
+)
+
+// openTestRepository migrates an in-memory SQLite database. It keeps a single
+// connection, as every new connection would get its own empty database.
+func openTestRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.RequireFTS5(db); errors.Is(err, database.ErrNoFTS5) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := database.NewMigrator(db, database.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLiteRepository(db)
+}
+
+func mustCreateQuestion(t *testing.T, repo BotRepository, text string, parentID int) int {
+	t.Helper()
+
+	id, err := repo.CreateQuestion(context.Background(), "en", text, "Answer", "", "", parentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSQLiteMoveQuestion(t *testing.T) {
+	ctx := context.Background()
+	repo := openTestRepository(t)
+
+	root := mustCreateQuestion(t, repo, "Root", 0)
+	child := mustCreateQuestion(t, repo, "Child", root)
+	other := mustCreateQuestion(t, repo, "Other", 0)
+	deleted := mustCreateQuestion(t, repo, "Deleted", 0)
+	if err := repo.DeleteQuestionByID(ctx, deleted, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		id       int
+		parentID int
+		want     error
+	}{
+		{"under itself", root, root, ErrQuestionCycle},
+		{"under its descendant", root, child, ErrQuestionCycle},
+		{"under a deleted question", child, deleted, ErrParentDeleted},
+		{"under a missing question", child, 1000, ErrQuestionNotFound},
+		{"deleted question", deleted, other, ErrQuestionNotFound},
+		{"under another question", child, other, nil},
+		{"to the top level", child, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.MoveQuestion(ctx, tt.id, tt.parentID); !errors.Is(err, tt.want) {
+				t.Fatalf("MoveQuestion(%d, %d) = %v, want %v", tt.id, tt.parentID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLitePurgeDeletedQuestions(t *testing.T) {
+	ctx := context.Background()
+	repo := openTestRepository(t)
+
+	live := mustCreateQuestion(t, repo, "Live", 0)
+	older := mustCreateQuestion(t, repo, "Older", 0)
+	olderChild := mustCreateQuestion(t, repo, "Older child", older)
+	newer := mustCreateQuestion(t, repo, "Newer", 0)
+	for _, id := range []int{olderChild, older, newer} {
+		if err := repo.DeleteQuestionByID(ctx, id, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	if _, err := repo.db.ExecContext(ctx, "UPDATE questions SET deleted_at = ? WHERE deleted_root_id IN (?, ?)",
+		now.Add(-48*time.Hour), older, olderChild); err != nil {
+		t.Fatal(err)
+	}
+	// A question left below a deleted one must not keep its subtree from being purged
+	orphan := mustCreateQuestion(t, repo, "Orphan", older)
+
+	n, err := repo.PurgeDeletedQuestions(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("purged %d questions, want 3", n)
+	}
+
+	for id, want := range map[int]bool{live: true, older: false, olderChild: false, orphan: false, newer: true} {
+		var exists bool
+		if err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) > 0 FROM questions WHERE id = ?", id).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if exists != want {
+			t.Errorf("question %d exists = %v, want %v", id, exists, want)
+		}
+	}
+}
+
+func TestSQLiteCreateQuestionWithFile(t *testing.T) {
+	ctx := context.Background()
+	repo := openTestRepository(t)
+
+	id, err := repo.CreateQuestion(ctx, "en", "Question", "Answer", fileTypeDoc, "file-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := repo.GetQuestionShallow(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.FileType != fileTypeDoc || q.FileID != "file-1" || q.Version != 1 {
+		t.Fatalf("got file %q %q at version %d, want %q %q at version 1", q.FileType, q.FileID, q.Version, fileTypeDoc, "file-1")
+	}
+}
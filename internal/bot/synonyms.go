@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Synonym makes search for Term also find Synonym, and the other way round.
+type Synonym struct {
+	ID      int    `json:"id"`
+	Lang    string `json:"lang"`
+	Term    string `json:"term"`
+	Synonym string `json:"synonym"`
+}
+
+func (s Synonym) String() string {
+	return fmt.Sprintf("#%d [%s] %s ↔ %s", s.ID, s.Lang, s.Term, s.Synonym)
+}
+
+// HandleSynonymsCommand implements /synonyms, managing the search synonyms.
+// Editors may change the synonyms of the languages they may edit.
+func (b *Bot) HandleSynonymsCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleSynonymsCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	perms := b.auth.Permissions(ctx, userID)
+	if !perms.CanView() {
+		return
+	}
+
+	args := strings.Fields(update.Message.Text)[1:]
+	lang := b.userLang(ctx, userID)
+
+	var text string
+	switch {
+	case len(args) == 0:
+		text = b.synonymsList(ctx, lang, "")
+	case args[0] == "add":
+		text = b.synonymsAdd(ctx, lang, userID, perms, strings.Join(args[1:], " "))
+	case args[0] == "remove":
+		text = b.synonymsRemove(ctx, lang, userID, perms, args[1:])
+	case len(args) == 1:
+		text = b.synonymsList(ctx, lang, args[0])
+	default:
+		text = b.t(lang, "synonyms.usage")
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: truncateText(text, maxMessageLength)})
+}
+
+// synonymsList lists the synonyms of synonymLang, or of every language when
+// it is empty.
+func (b *Bot) synonymsList(ctx context.Context, lang, synonymLang string) string {
+	synonyms, err := b.repository.ListSynonyms(ctx, synonymLang)
+	if err != nil {
+		return b.t(lang, "synonyms.list_failed")
+	}
+	if len(synonyms) == 0 {
+		return b.t(lang, "synonyms.none") + "\n\n" + b.t(lang, "synonyms.usage")
+	}
+
+	lines := []string{b.t(lang, "synonyms.list")}
+	for _, s := range synonyms {
+		lines = append(lines, s.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// synonymsAdd parses "<lang> <term> = <synonym>"; both sides may span several words.
+func (b *Bot) synonymsAdd(ctx context.Context, lang string, userID int64, perms *Permissions, args string) string {
+	synonymLang, rest, ok := strings.Cut(args, " ")
+	term, synonym, found := strings.Cut(rest, "=")
+	s := Synonym{
+		Lang:    synonymLang,
+		Term:    strings.ToLower(strings.Join(strings.Fields(term), " ")),
+		Synonym: strings.ToLower(strings.Join(strings.Fields(synonym), " ")),
+	}
+	if !ok || !found || s.Term == "" || s.Synonym == "" {
+		return b.t(lang, "synonyms.usage")
+	}
+	if !b.catalog.Has(s.Lang) {
+		return b.t(lang, "synonyms.unknown_language", s.Lang)
+	}
+	if !perms.Allows(ActionEdit, s.Lang, nil) {
+		return b.t(lang, "synonyms.not_allowed", s.Lang)
+	}
+
+	err := b.repository.WithTx(ctx, func(tx BotRepository) error {
+		var err error
+		if s.ID, err = tx.AddSynonym(ctx, s); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditSynonymAdd}, nil, s)
+	})
+	if err != nil {
+		return b.t(lang, "synonyms.save_failed")
+	}
+	return b.t(lang, "synonyms.added", s.String())
+}
+
+func (b *Bot) synonymsRemove(ctx context.Context, lang string, userID int64, perms *Permissions, args []string) string {
+	if len(args) != 1 {
+		return b.t(lang, "synonyms.usage")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return b.t(lang, "synonyms.invalid_id")
+	}
+
+	synonyms, err := b.repository.ListSynonyms(ctx, "")
+	if err != nil {
+		return b.t(lang, "synonyms.remove_failed")
+	}
+	var removed *Synonym
+	for i := range synonyms {
+		if synonyms[i].ID == id {
+			removed = &synonyms[i]
+		}
+	}
+	if removed == nil {
+		return b.t(lang, "synonyms.not_found", id)
+	}
+	if !perms.Allows(ActionEdit, removed.Lang, nil) {
+		return b.t(lang, "synonyms.not_allowed", removed.Lang)
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.DeleteSynonym(ctx, id); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditSynonymRemove}, removed, nil)
+	})
+	if err != nil {
+		if errors.Is(err, ErrSynonymNotFound) {
+			return b.t(lang, "synonyms.not_found", id)
+		}
+		return b.t(lang, "synonyms.remove_failed")
+	}
+	return b.t(lang, "synonyms.removed", id)
+}
@@ -0,0 +1,299 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxListedMissing limits the questions shown by /translations.
+const maxListedMissing = 30
+
+// translationGroup is the audit payload of translation changes.
+type translationGroup struct {
+	Questions []int `json:"questions"`
+}
+
+// addTranslationsButton adds the "Other languages" button under the answer
+// of a question that has translations.
+func (b *Bot) addTranslationsButton(lang string, keyboard *models.InlineKeyboardMarkup, q *Question) {
+	if q.TranslationGroup == 0 {
+		return
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []models.InlineKeyboardButton{
+		{
+			Text:         b.t(lang, "questions.other_languages"),
+			CallbackData: fmt.Sprintf("tr_%d", q.ID),
+		},
+	})
+}
+
+// HandleTranslationsCallback opens the translation of a question, or lets the
+// user choose when there are several.
+func (b *Bot) HandleTranslationsCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleTranslationsCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "tr_"))
+	if err != nil {
+		return
+	}
+
+	userID := update.CallbackQuery.From.ID
+	lang := b.userLang(ctx, userID)
+
+	translations, err := b.repository.GetTranslations(ctx, id)
+	if err != nil {
+		log.Println("failed to get translations: ", err)
+	}
+	if len(translations) == 0 {
+		tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            b.t(lang, "translations.none"),
+		})
+		return
+	}
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+
+	if len(translations) > 1 {
+		var rows [][]models.InlineKeyboardButton
+		for _, t := range translations {
+			rows = append(rows, []models.InlineKeyboardButton{
+				{Text: fmt.Sprintf("[%s] %s", t.Lang, t.Text), CallbackData: fmt.Sprintf("q_%d", t.ID)},
+			})
+		}
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        b.t(lang, "translations.choose"),
+			ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+		})
+		return
+	}
+
+	q, err := b.repository.GetQuestionByID(ctx, translations[0].ID)
+	if err != nil {
+		return
+	}
+
+	tbot.DeleteMessage(ctx, &tgbot.DeleteMessageParams{
+		ChatID:    chatID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+	b.sendQuestion(ctx, tbot, chatID, userID, q)
+}
+
+// HandleTranslationsCommand implements /translations: the report of missing
+// translations and the linking of questions into translation groups.
+func (b *Bot) HandleTranslationsCommand(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleTranslationsCommand received from user %d: %s\n", update.Message.From.ID, update.Message.Text)
+
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	if !b.auth.Permissions(ctx, userID).CanView() {
+		return
+	}
+
+	args := strings.Fields(update.Message.Text)[1:]
+	lang := b.userLang(ctx, userID)
+
+	var text string
+	switch {
+	case len(args) == 0:
+		text = b.missingTranslations(ctx, lang, "")
+	case args[0] == "link":
+		text = b.translationsLink(ctx, lang, userID, args[1:])
+	case args[0] == "unlink":
+		text = b.translationsUnlink(ctx, lang, userID, args[1:])
+	case len(args) == 1 && b.catalog.Has(args[0]):
+		text = b.missingTranslations(ctx, lang, args[0])
+	default:
+		text = b.t(lang, "translations.usage")
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: truncateText(text, maxMessageLength)})
+}
+
+// missingTranslations lists the questions lacking a translation in any
+// language, or in missingIn when it is not empty.
+func (b *Bot) missingTranslations(ctx context.Context, lang, missingIn string) string {
+	questions, err := b.repository.ListQuestions(ctx, "")
+	if err != nil {
+		log.Println("failed to list questions: ", err)
+		return b.t(lang, "translations.load_failed")
+	}
+
+	translated := make(map[int][]string) // translation group -> languages
+	for _, q := range questions {
+		if q.TranslationGroup != 0 {
+			translated[q.TranslationGroup] = append(translated[q.TranslationGroup], q.Lang)
+		}
+	}
+
+	var lines []string
+	total := 0
+	for _, q := range questions {
+		have := translated[q.TranslationGroup]
+		if q.TranslationGroup == 0 {
+			have = []string{q.Lang}
+		}
+
+		var missing []string
+		for _, l := range b.catalog.Languages() {
+			if !slices.Contains(have, l.Code) && (missingIn == "" || l.Code == missingIn) {
+				missing = append(missing, l.Code)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		total++
+		if len(lines) < maxListedMissing {
+			lines = append(lines, b.t(lang, "translations.missing", q.ID, q.Lang, q.Text, strings.Join(missing, ", ")))
+		}
+	}
+
+	if total == 0 {
+		return b.t(lang, "translations.complete")
+	}
+	header := b.t(lang, "translations.title", total)
+	if total > len(lines) {
+		header = b.t(lang, "translations.title_first", total, len(lines))
+	}
+	return header + "\n" + strings.Join(lines, "\n") + "\n\n" + b.t(lang, "translations.hint")
+}
+
+func (b *Bot) translationsLink(ctx context.Context, lang string, userID int64, args []string) string {
+	if len(args) != 2 {
+		return b.t(lang, "translations.usage")
+	}
+
+	var questions [2]*Question
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return b.t(lang, "translations.invalid_id")
+		}
+		if questions[i], err = b.repository.GetQuestionShallow(ctx, id); err != nil {
+			return b.t(lang, "common.question_not_found", id)
+		}
+		if !b.auth.Can(ctx, userID, ActionEdit, questions[i]) {
+			return b.t(lang, "translations.not_allowed", id)
+		}
+	}
+
+	q, other := questions[0], questions[1]
+	if q.ID == other.ID {
+		return b.t(lang, "translations.self")
+	}
+	if q.TranslationGroup != 0 && q.TranslationGroup == other.TranslationGroup {
+		return b.t(lang, "translations.already_linked", q.ID, other.ID)
+	}
+
+	// A group holds at most one question per language
+	group, err := b.translationGroup(ctx, q)
+	if err != nil {
+		return b.t(lang, "translations.link_failed")
+	}
+	otherGroup, err := b.translationGroup(ctx, other)
+	if err != nil {
+		return b.t(lang, "translations.link_failed")
+	}
+	for _, a := range group {
+		for _, o := range otherGroup {
+			if a.Lang == o.Lang {
+				return b.t(lang, "translations.same_language", a.ID, o.ID, a.Lang)
+			}
+		}
+	}
+
+	linked := append(group, otherGroup...)
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.LinkTranslations(ctx, q.ID, other.ID); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditTranslationLink, QuestionID: q.ID},
+			translationGroup{Questions: questionIDs(group)}, translationGroup{Questions: questionIDs(linked)})
+	})
+	if err != nil {
+		log.Println("failed to link translations: ", err)
+		return b.t(lang, "translations.link_failed")
+	}
+
+	lines := []string{b.t(lang, "translations.linked")}
+	for _, t := range linked {
+		lines = append(lines, fmt.Sprintf("#%d [%s] %s", t.ID, t.Lang, t.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) translationsUnlink(ctx context.Context, lang string, userID int64, args []string) string {
+	if len(args) != 1 {
+		return b.t(lang, "translations.usage")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return b.t(lang, "translations.invalid_id")
+	}
+	q, err := b.repository.GetQuestionShallow(ctx, id)
+	if err != nil {
+		return b.t(lang, "common.question_not_found", id)
+	}
+	if !b.auth.Can(ctx, userID, ActionEdit, q) {
+		return b.t(lang, "translations.not_allowed", id)
+	}
+	if q.TranslationGroup == 0 {
+		return b.t(lang, "translations.no_translations", id)
+	}
+
+	group, err := b.translationGroup(ctx, q)
+	if err != nil {
+		return b.t(lang, "translations.unlink_failed")
+	}
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.UnlinkTranslation(ctx, id); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditTranslationUnlink, QuestionID: id},
+			translationGroup{Questions: questionIDs(group)}, nil)
+	})
+	if err != nil {
+		log.Println("failed to unlink translation: ", err)
+		return b.t(lang, "translations.unlink_failed")
+	}
+	return b.t(lang, "translations.unlinked", id)
+}
+
+// translationGroup returns a question followed by its translations.
+func (b *Bot) translationGroup(ctx context.Context, q *Question) ([]Question, error) {
+	translations, err := b.repository.GetTranslations(ctx, q.ID)
+	if err != nil {
+		log.Println("failed to get translations: ", err)
+		return nil, err
+	}
+	return append([]Question{*q}, translations...), nil
+}
+
+func questionIDs(questions []Question) []int {
+	ids := make([]int, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxListedTrash limits the deleted subtrees shown by /trash.
+const maxListedTrash = 20
+
+// DeletedQuestion is the root of a soft-deleted subtree.
+type DeletedQuestion struct {
+	Question
+	DeletedAt   time.Time `json:"deleted_at"`
+	DeletedBy   int64     `json:"deleted_by"`
+	Descendants int       `json:"descendants"`
+}
+
+// HandleTrash lists deleted subtrees with who deleted them and, for editors, a
+// restore button each.
+func (b *Bot) HandleTrash(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	fmt.Printf("HandleTrash called by user %d\n", update.Message.From.ID)
+
+	chatID := update.Message.Chat.ID
+	perms := b.auth.Permissions(ctx, update.Message.From.ID)
+	if !perms.CanView() {
+		return
+	}
+	lang := b.userLang(ctx, update.Message.From.ID)
+
+	deleted, err := b.repository.ListDeletedQuestions(ctx)
+	if err != nil {
+		log.Println("failed to list deleted questions: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "trash.failed")})
+		return
+	}
+	if len(deleted) == 0 {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "trash.empty")})
+		return
+	}
+
+	lines := []string{b.t(lang, "trash.title")}
+	var rows [][]models.InlineKeyboardButton
+	for i, d := range deleted {
+		if i == maxListedTrash {
+			lines = append(lines, b.t(lang, "common.more", len(deleted)-maxListedTrash))
+			break
+		}
+		lines = append(lines, "\n"+b.t(lang, "trash.entry",
+			d.ID, d.Lang, d.Text, d.Descendants, d.DeletedAt.Format("2006-01-02 15:04"), d.DeletedBy))
+		if perms.CanEdit() {
+			rows = append(rows, []models.InlineKeyboardButton{
+				{Text: b.t(lang, "trash.restore", d.ID), CallbackData: fmt.Sprintf("trrest_%d", d.ID)},
+			})
+		}
+	}
+
+	params := &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   truncateText(strings.Join(lines, "\n"), maxMessageLength),
+	}
+	if len(rows) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+	tbot.SendMessage(ctx, params)
+}
+
+// HandleTrashRestore restores a deleted subtree under its original parent.
+func (b *Bot) HandleTrashRestore(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleTrashRestore received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	id, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "trrest_"))
+	if err != nil {
+		return
+	}
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	lang := b.userLang(ctx, userID)
+
+	deleted, err := b.findDeleted(ctx, id)
+	if err != nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "trash.not_found", id)})
+		return
+	}
+
+	// Whoever may delete the question may also bring it back
+	if !b.auth.Can(ctx, userID, ActionDelete, &deleted.Question) {
+		return
+	}
+
+	err = b.repository.WithTx(ctx, func(tx BotRepository) error {
+		if err := tx.RestoreQuestion(ctx, id); err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionRestore, QuestionID: id}, nil, &deleted.Question)
+	})
+	switch {
+	case errors.Is(err, ErrParentDeleted):
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+			ChatID: chatID,
+			Text:   b.t(lang, "trash.parent_deleted", id, deleted.ParentID),
+		})
+		return
+	case err != nil:
+		log.Println("failed to restore question: ", err)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "trash.restore_failed")})
+		return
+	}
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID: chatID,
+		Text:   b.t(lang, "trash.restored", id, deleted.Descendants),
+	})
+}
+
+func (b *Bot) findDeleted(ctx context.Context, id int) (*DeletedQuestion, error) {
+	deleted, err := b.repository.ListDeletedQuestions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deleted {
+		if deleted[i].ID == id {
+			return &deleted[i], nil
+		}
+	}
+	return nil, ErrQuestionNotFound
+}
+
+// RunTrashPurge hard-deletes questions that have been in the trash longer
+// than retention, checking once per interval until ctx is done.
+func (b *Bot) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := b.repository.PurgeDeletedQuestions(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed to purge deleted questions: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d deleted question(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
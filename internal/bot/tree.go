@@ -0,0 +1,51 @@
+package bot
+
+// questionTreeQuery returns the query loading whole question subtrees in one
+// round trip. rootCondition selects the roots and holds the only placeholders,
+// so the query works with both backends. Rows come out in display order within
+// each parent, which buildQuestionTree relies on.
+func questionTreeQuery(rootCondition string) string {
+	return `WITH RECURSIVE tree(id) AS (
+            SELECT id FROM questions WHERE ` + rootCondition + ` AND deleted_at IS NULL
+            UNION ALL
+            SELECT q.id FROM questions q JOIN tree t ON q.parent_id = t.id WHERE q.deleted_at IS NULL
+        )
+        SELECT q.id, q.lang, q.text, q.answer, q.file_type, q.file_id, q.parent_id, q.translation_group, q.version
+        FROM questions q JOIN tree t ON q.id = t.id
+        ORDER BY q.position, q.id`
+}
+
+// buildQuestionTree nests the rows of questionTreeQuery into SubQuestions and
+// returns the roots, i.e. the questions whose parent is not among the rows.
+func buildQuestionTree(rows []Question) []Question {
+	loaded := make(map[int]bool, len(rows))
+	for _, q := range rows {
+		loaded[q.ID] = true
+	}
+
+	children := make(map[int][]int) // parent ID -> row indexes
+	var roots []int
+	for i, q := range rows {
+		if q.ParentID != 0 && loaded[q.ParentID] {
+			children[q.ParentID] = append(children[q.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(i int) Question
+	build = func(i int) Question {
+		q := rows[i]
+		q.SubQuestions = make([]Question, 0, len(children[q.ID]))
+		for _, c := range children[q.ID] {
+			q.SubQuestions = append(q.SubQuestions, build(c))
+		}
+		return q
+	}
+
+	tree := make([]Question, 0, len(roots))
+	for _, i := range roots {
+		tree = append(tree, build(i))
+	}
+	return tree
+}
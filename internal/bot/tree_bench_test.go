@@ -0,0 +1,288 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"qaBot/internal/infrastructure/database"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The benchmarks compare loading question trees with one recursive query
+// against the previous approach of one query per question:
+//
+//	go test -tags sqlite_fts5 -run '^$' -bench . ./internal/bot
+//
+// SQLite runs on a temporary file. PostgreSQL runs when QABOT_BENCH_POSTGRES_DSN
+// points at a scratch database, which is migrated and filled with questions.
+const (
+	benchLang   = "en"
+	benchRoots  = 10 // top-level questions
+	benchFanout = 4  // sub-questions per question
+	benchDepth  = 3  // levels of sub-questions below the top level
+)
+
+// benchDatabase is a seeded backend.
+type benchDatabase struct {
+	repo    BotRepository
+	rootIDs []int
+}
+
+// benchBackends seeds the backends to benchmark. They are closed, and the
+// questions seeded into PostgreSQL deleted, when the benchmark ends.
+func benchBackends(b *testing.B) map[string]*benchDatabase {
+	backends := map[string]*benchDatabase{database.DialectSQLite: seedBenchDatabase(b, database.DialectSQLite)}
+	if os.Getenv("QABOT_BENCH_POSTGRES_DSN") != "" {
+		backends[database.DialectPostgres] = seedBenchDatabase(b, database.DialectPostgres)
+	}
+	return backends
+}
+
+// seedBenchDatabase migrates a scratch database, fills it with questions and
+// makes sure both loaders see the same trees before they are timed.
+func seedBenchDatabase(b *testing.B, driver string) *benchDatabase {
+	ctx := context.Background()
+
+	var (
+		sqlDB *sql.DB
+		repo  BotRepository
+	)
+	switch driver {
+	case database.DialectSQLite:
+		var err error
+		if sqlDB, err = sql.Open("sqlite3", database.SQLiteDSN(filepath.Join(b.TempDir(), "bench.db"))); err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { sqlDB.Close() })
+		if err := database.RequireFTS5(sqlDB); errors.Is(err, database.ErrNoFTS5) {
+			b.Skip(err)
+		} else if err != nil {
+			b.Fatal(err)
+		}
+		repo = NewSQLiteRepository(sqlDB)
+	case database.DialectPostgres:
+		pool, err := pgxpool.New(ctx, os.Getenv("QABOT_BENCH_POSTGRES_DSN"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(pool.Close)
+		sqlDB, repo = stdlib.OpenDBFromPool(pool), NewRepository(pool)
+		b.Cleanup(func() { sqlDB.Close() })
+	}
+
+	migrator, err := database.NewMigrator(sqlDB, driver)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		b.Fatalf("%s: %v", driver, err)
+	}
+
+	rootIDs, err := seedBenchQuestions(ctx, repo)
+	if driver == database.DialectPostgres {
+		// Deleting the top-level questions cascades to their subtrees
+		b.Cleanup(func() {
+			for _, id := range rootIDs {
+				if _, err := sqlDB.ExecContext(ctx, "DELETE FROM questions WHERE id = $1", id); err != nil {
+					b.Errorf("delete seeded question %d: %v", id, err)
+				}
+			}
+		})
+	}
+	if err != nil {
+		b.Fatalf("%s: %v", driver, err)
+	}
+	if err := checkSameTrees(ctx, repo, rootIDs[0]); err != nil {
+		b.Fatalf("%s: %v", driver, err)
+	}
+	return &benchDatabase{repo: repo, rootIDs: rootIDs}
+}
+
+// seedBenchQuestions creates benchRoots top-level questions, each with
+// benchFanout sub-questions per level down to benchDepth levels, and returns
+// the IDs of the top-level ones.
+func seedBenchQuestions(ctx context.Context, repo BotRepository) ([]int, error) {
+	var rootIDs []int
+
+	var create func(parentID int, prefix string, level int) error
+	create = func(parentID int, prefix string, level int) error {
+		count := benchFanout
+		if level == 0 {
+			count = benchRoots
+		}
+		for i := 1; i <= count; i++ {
+			text := fmt.Sprintf("%s%d", prefix, i)
+			id, err := repo.CreateQuestion(ctx, benchLang, "Question "+text, "Answer "+text, "", "", parentID)
+			if err != nil {
+				return err
+			}
+			if level == 0 {
+				rootIDs = append(rootIDs, id)
+			}
+			if level < benchDepth {
+				if err := create(id, text+".", level+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// The IDs created before a failure are returned too, to be cleaned up
+	err := create(0, "", 0)
+	return rootIDs, err
+}
+
+// legacyQuestionsByLang loads the top-level questions of a language and then
+// their subtrees with one query per question, as the repositories did before
+// the recursive query.
+func legacyQuestionsByLang(ctx context.Context, repo BotRepository, lang string) ([]Question, error) {
+	questions, err := repo.ListQuestions(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []Question
+	for _, q := range questions {
+		if q.ParentID != 0 {
+			continue
+		}
+		if q.SubQuestions, err = legacySubQuestions(ctx, repo, q.ID); err != nil {
+			return nil, err
+		}
+		roots = append(roots, q)
+	}
+	return roots, nil
+}
+
+// legacySubQuestions loads the subtree below a question with one query per
+// question.
+func legacySubQuestions(ctx context.Context, repo BotRepository, parentID int) ([]Question, error) {
+	subQuestions, err := repo.GetSubQuestions(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subQuestions {
+		if subQuestions[i].SubQuestions, err = legacySubQuestions(ctx, repo, subQuestions[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return subQuestions, nil
+}
+
+func checkSameTrees(ctx context.Context, repo BotRepository, id int) error {
+	recursive, err := repo.GetQuestionsByLang(ctx, benchLang)
+	if err != nil {
+		return err
+	}
+	legacy, err := legacyQuestionsByLang(ctx, repo, benchLang)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(treeIDs(recursive), treeIDs(legacy)) {
+		return fmt.Errorf("GetQuestionsByLang differs from the per-question load")
+	}
+
+	q, err := repo.GetQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	subQuestions, err := legacySubQuestions(ctx, repo, id)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(treeIDs(q.SubQuestions), treeIDs(subQuestions)) {
+		return fmt.Errorf("GetQuestionByID(%d) differs from the per-question load", id)
+	}
+	return nil
+}
+
+// treeIDs flattens a tree into its question IDs in depth-first order, with 0
+// closing every list of sub-questions so that the shape is compared too.
+func treeIDs(questions []Question) []int {
+	var ids []int
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+		ids = append(ids, treeIDs(q.SubQuestions)...)
+	}
+	return append(ids, 0)
+}
+
+// benchLoad is one way of loading questions being timed.
+type benchLoad struct {
+	name string
+	load func(ctx context.Context, db *benchDatabase) error
+}
+
+// runBenchLoads runs every load on every backend, serially and in parallel.
+func runBenchLoads(b *testing.B, loads []benchLoad) {
+	ctx := context.Background()
+	backends := benchBackends(b)
+	for _, backend := range []string{database.DialectSQLite, database.DialectPostgres} {
+		db, ok := backends[backend]
+		if !ok {
+			continue
+		}
+		for _, l := range loads {
+			name, load := l.name, l.load
+			b.Run(backend+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := load(ctx, db); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(backend+"/"+name+"/parallel", func(b *testing.B) {
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if err := load(ctx, db); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkGetQuestionsByLang(b *testing.B) {
+	runBenchLoads(b, []benchLoad{
+		{"recursive", func(ctx context.Context, db *benchDatabase) error {
+			_, err := db.repo.GetQuestionsByLang(ctx, benchLang)
+			return err
+		}},
+		{"per-question", func(ctx context.Context, db *benchDatabase) error {
+			_, err := legacyQuestionsByLang(ctx, db.repo, benchLang)
+			return err
+		}},
+	})
+}
+
+func BenchmarkGetQuestionByID(b *testing.B) {
+	runBenchLoads(b, []benchLoad{
+		{"recursive", func(ctx context.Context, db *benchDatabase) error {
+			_, err := db.repo.GetQuestionByID(ctx, db.rootIDs[0])
+			return err
+		}},
+		{"per-question", func(ctx context.Context, db *benchDatabase) error {
+			_, err := legacySubQuestions(ctx, db.repo, db.rootIDs[0])
+			return err
+		}},
+		{"shallow", func(ctx context.Context, db *benchDatabase) error {
+			_, err := db.repo.GetQuestionShallow(ctx, db.rootIDs[0])
+			return err
+		}},
+	})
+}
@@ -0,0 +1,526 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbot "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// WizardStep is a state of the add/edit question wizard.
+type WizardStep string
+
+const (
+	stepQuestion WizardStep = "question"
+	stepAnswer   WizardStep = "answer"
+	stepFile     WizardStep = "file"
+	stepPreview  WizardStep = "preview"
+)
+
+// Wizard callback data. None of them share a prefix with another handler.
+const (
+	wizardBack       = "wiz_back"
+	wizardSkip       = "wiz_skip"
+	wizardRemoveFile = "wiz_rmfile"
+	wizardCancel     = "wiz_cancel"
+	wizardConfirm    = "wiz_confirm"
+	wizardOverwrite  = "wiz_overwrite"
+	wizardReopen     = "wiz_reopen"
+)
+
+// wizardShortcutPrefix starts the `qa: question | answer` shortcut, which fills
+// in both at once. Without it a "|" is just part of the question.
+const wizardShortcutPrefix = "qa:"
+
+// wizardNext and wizardPrev define the transitions of the wizard state machine.
+var (
+	wizardNext = map[WizardStep]WizardStep{
+		stepQuestion: stepAnswer,
+		stepAnswer:   stepFile,
+		stepFile:     stepPreview,
+	}
+	wizardPrev = map[WizardStep]WizardStep{
+		stepAnswer:  stepQuestion,
+		stepFile:    stepAnswer,
+		stepPreview: stepFile,
+	}
+	wizardStepNumber = map[WizardStep]int{
+		stepQuestion: 1,
+		stepAnswer:   2,
+		stepFile:     3,
+		stepPreview:  4,
+	}
+)
+
+// canSkip reports whether the current step may be left without input. Text
+// and answer are required; once they have a value, such as when editing,
+// skipping keeps it.
+func (d *PendingQuestionData) canSkip() bool {
+	switch d.Step {
+	case stepQuestion:
+		return d.Text != ""
+	case stepAnswer:
+		return d.Answer != ""
+	case stepFile:
+		return true
+	default:
+		return false
+	}
+}
+
+// startWizard saves a fresh session and sends the first step.
+func (b *Bot) startWizard(ctx context.Context, tbot *tgbot.Bot, chatID, userID int64, session *PendingQuestionData) {
+	session.Step = stepQuestion
+	if err := b.sessions.Save(ctx, userID, session); err != nil {
+		log.Println("failed to save session: ", err)
+		return
+	}
+
+	b.sendWizardStep(ctx, tbot, chatID, b.userLang(ctx, userID), session)
+}
+
+// sendWizardStep sends the prompt and Back/Skip/Cancel buttons of the current
+// step in the admin's language.
+func (b *Bot) sendWizardStep(ctx context.Context, tbot *tgbot.Bot, chatID int64, lang string, session *PendingQuestionData) {
+	title := b.t(lang, "wizard.new_title", session.Lang, session.ParentID)
+	if session.EditID != nil {
+		title = b.t(lang, "wizard.edit_title", *session.EditID)
+	}
+	header := b.t(lang, "wizard.step", title, wizardStepNumber[session.Step], len(wizardStepNumber)) + "\n\n"
+
+	var text string
+	switch session.Step {
+	case stepQuestion:
+		text = b.t(lang, "wizard.question")
+		if session.Text != "" {
+			text += "\n\n" + b.t(lang, "wizard.current", session.Text)
+		}
+		text += "\n\n" + b.t(lang, "wizard.shortcut", wizardShortcutPrefix)
+	case stepAnswer:
+		text = b.t(lang, "wizard.answer")
+		if session.Answer != "" {
+			text += "\n\n" + b.t(lang, "wizard.current", session.Answer)
+		}
+	case stepFile:
+		text = b.t(lang, "wizard.file")
+		if session.FileType != "" {
+			text += "\n\n" + b.t(lang, "wizard.current_file", b.describeFile(lang, session.FileType))
+		}
+	case stepPreview:
+		text = b.t(lang, "wizard.preview", b.describePendingQuestion(ctx, lang, session))
+	}
+
+	var row []models.InlineKeyboardButton
+	if _, ok := wizardPrev[session.Step]; ok {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "wizard.back"), CallbackData: wizardBack})
+	}
+	if session.canSkip() {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "wizard.skip"), CallbackData: wizardSkip})
+	}
+	if session.Step == stepFile && session.FileType != "" {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "wizard.remove_file"), CallbackData: wizardRemoveFile})
+	}
+	if session.Step == stepPreview {
+		row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "wizard.confirm"), CallbackData: stampCallback(wizardConfirm)})
+	}
+	row = append(row, models.InlineKeyboardButton{Text: b.t(lang, "common.cancel"), CallbackData: wizardCancel})
+
+	tbot.SendMessage(ctx, &tgbot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        header + text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}},
+	})
+}
+
+// describePendingQuestion renders the preview. For edits it shows exactly
+// which fields change compared to the stored question.
+func (b *Bot) describePendingQuestion(ctx context.Context, lang string, session *PendingQuestionData) string {
+	if session.EditID == nil {
+		return b.describeContent(lang, session.Text, session.Answer, session.FileType)
+	}
+
+	current, err := b.repository.GetQuestionShallow(ctx, *session.EditID)
+	if err != nil {
+		log.Printf("Failed to fetch question ID %d: %v", *session.EditID, err)
+		return b.t(lang, "common.question_gone")
+	}
+
+	var changes []string
+	if current.Text != session.Text {
+		changes = append(changes, b.t(lang, "content.text_change", current.Text, session.Text))
+	}
+	if current.Answer != session.Answer {
+		changes = append(changes, b.t(lang, "content.answer_change", current.Answer, session.Answer))
+	}
+	if current.FileType != session.FileType || current.FileID != session.FileID {
+		changes = append(changes, b.t(lang, "content.file_change", b.describeFile(lang, current.FileType), b.describeFile(lang, session.FileType)))
+	}
+	if len(changes) == 0 {
+		return b.t(lang, "wizard.no_changes")
+	}
+	return strings.Join(changes, "\n\n")
+}
+
+func (b *Bot) describeContent(lang, text, answer, fileType string) string {
+	return b.t(lang, "content.summary", text, answer, b.describeFile(lang, fileType))
+}
+
+func (b *Bot) describeFile(lang, fileType string) string {
+	switch fileType {
+	case "":
+		return b.t(lang, "file.none")
+	case fileTypeDoc, fileTypePhoto:
+		return b.t(lang, "file."+fileType)
+	}
+	return fileType
+}
+
+// handleWizardInput feeds a message from the admin into the current wizard step.
+func (b *Bot) handleWizardInput(ctx context.Context, tbot *tgbot.Bot, msg *models.Message, session *PendingQuestionData) {
+	chatID := msg.Chat.ID
+	lang := b.userLang(ctx, msg.From.ID)
+
+	text := msg.Text
+	if msg.Caption != "" {
+		text = msg.Caption
+	}
+	text = strings.TrimSpace(text)
+
+	fileType, fileID := messageFile(msg)
+
+	// Sessions saved before the wizard existed start from the beginning
+	if session.Step == "" {
+		session.Step = stepQuestion
+	}
+
+	switch session.Step {
+	case stepQuestion:
+		if text == "" {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.need_question")})
+			return
+		}
+
+		// Shortcut straight to the preview, in the old `question|answer` format.
+		// Phone keyboards capitalize the prefix, so its case does not matter.
+		n := len(wizardShortcutPrefix)
+		if len(text) >= n && strings.EqualFold(text[:n], wizardShortcutPrefix) {
+			q, a, _ := strings.Cut(text[n:], "|")
+			q, a = strings.TrimSpace(q), strings.TrimSpace(a)
+			if q == "" || a == "" {
+				tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.shortcut", wizardShortcutPrefix)})
+				return
+			}
+			session.Text, session.Answer = q, a
+			if fileType != "" {
+				session.FileType, session.FileID = fileType, fileID
+			}
+			session.Step = stepPreview
+			break
+		}
+
+		session.Text = text
+		session.Step = wizardNext[stepQuestion]
+	case stepAnswer:
+		if text == "" {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.need_answer")})
+			return
+		}
+		session.Answer = text
+		session.Step = wizardNext[stepAnswer]
+	case stepFile:
+		if fileType == "" {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.need_file")})
+			return
+		}
+		session.FileType, session.FileID = fileType, fileID
+		session.Step = wizardNext[stepFile]
+	case stepPreview:
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.preview_hint")})
+		return
+	}
+
+	if err := b.sessions.Save(ctx, msg.From.ID, session); err != nil {
+		log.Println("failed to save session: ", err)
+		return
+	}
+	b.sendWizardStep(ctx, tbot, chatID, lang, session)
+}
+
+// HandleWizardCallback handles the Back/Skip/Remove file/Cancel/Confirm buttons.
+func (b *Bot) HandleWizardCallback(ctx context.Context, tbot *tgbot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+
+	fmt.Printf("HandleWizardCallback received: %s from user %d\n", update.CallbackQuery.Data, update.CallbackQuery.From.ID)
+
+	userID := update.CallbackQuery.From.ID
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+
+	tbot.AnswerCallbackQuery(ctx, &tgbot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	session, err := b.loadSession(ctx, tbot, chatID, userID)
+	if err != nil {
+		return
+	}
+	lang := b.userLang(ctx, userID)
+	if session == nil {
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "session.none")})
+		return
+	}
+
+	// Remove the buttons of the step that was answered
+	tbot.EditMessageReplyMarkup(ctx, &tgbot.EditMessageReplyMarkupParams{
+		ChatID:    chatID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+
+	data := update.CallbackQuery.Data
+	if strings.HasPrefix(data, wizardConfirm) || strings.HasPrefix(data, wizardOverwrite) {
+		base, expired, ok := parseStampedCallback(data)
+		if !ok {
+			return
+		}
+		if expired {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "wizard.expired")})
+			b.sendWizardStep(ctx, tbot, chatID, lang, session)
+			return
+		}
+		data = base
+	}
+
+	switch data {
+	case wizardCancel:
+		if err := b.sessions.Delete(ctx, userID); err != nil {
+			log.Println("failed to clear session: ", err)
+		}
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "common.cancelled")})
+		return
+	case wizardConfirm:
+		if session.Step != stepPreview {
+			return
+		}
+		text, keyboard := b.saveWizard(ctx, lang, userID, session)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: keyboard})
+		return
+	case wizardOverwrite:
+		if session.Step != stepPreview || session.EditID == nil || session.ConflictVersion == 0 {
+			return
+		}
+		// Save over the version the conflict showed; newer changes still conflict
+		session.Version, session.ConflictVersion = session.ConflictVersion, 0
+		text, keyboard := b.saveWizard(ctx, lang, userID, session)
+		tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: keyboard})
+		return
+	case wizardReopen:
+		if session.EditID == nil {
+			return
+		}
+		current, err := b.repository.GetQuestionShallow(ctx, *session.EditID)
+		if err != nil || !b.auth.Can(ctx, userID, ActionEdit, current) {
+			tbot.SendMessage(ctx, &tgbot.SendMessageParams{ChatID: chatID, Text: b.t(lang, "common.question_gone")})
+			return
+		}
+		b.startWizard(ctx, tbot, chatID, userID, editSession(current))
+		return
+	case wizardBack:
+		prev, ok := wizardPrev[session.Step]
+		if !ok {
+			return
+		}
+		session.Step = prev
+	case wizardSkip:
+		if !session.canSkip() {
+			return
+		}
+		session.Step = wizardNext[session.Step]
+	case wizardRemoveFile:
+		if session.Step != stepFile {
+			return
+		}
+		session.FileType, session.FileID = "", ""
+		session.Step = wizardNext[stepFile]
+	default:
+		return
+	}
+
+	if err := b.sessions.Save(ctx, userID, session); err != nil {
+		log.Println("failed to save session: ", err)
+		return
+	}
+	b.sendWizardStep(ctx, tbot, chatID, lang, session)
+}
+
+// saveWizard writes the confirmed question and clears the session. It returns
+// the message to show to the admin, with buttons when the edit conflicts with
+// someone else's.
+func (b *Bot) saveWizard(ctx context.Context, lang string, userID int64, session *PendingQuestionData) (string, *models.InlineKeyboardMarkup) {
+	// Rights may have changed since the session started
+	allowed := false
+	if session.EditID != nil {
+		if q, err := b.repository.GetQuestionShallow(ctx, *session.EditID); err == nil {
+			allowed = b.auth.Can(ctx, userID, ActionEdit, q)
+		}
+	} else {
+		allowed = b.auth.CanAdd(ctx, userID, session.Lang, session.ParentID)
+	}
+	if !allowed {
+		b.sessions.Delete(ctx, userID)
+		return b.t(lang, "wizard.not_allowed"), nil
+	}
+
+	if session.EditID != nil {
+		err := b.saveEdit(ctx, userID, session)
+		if errors.Is(err, ErrVersionConflict) {
+			// The session stays, so the admin can overwrite or start over
+			return b.describeConflict(ctx, lang, userID, session)
+		}
+		if err != nil {
+			log.Println("failed to update question: ", err)
+			return b.t(lang, "wizard.update_failed"), nil
+		}
+	} else {
+		qID, err := b.saveNew(ctx, userID, session)
+		if err != nil {
+			log.Println("failed to create question: ", err)
+			return b.t(lang, "wizard.create_failed"), nil
+		}
+		log.Println("question: ", qID)
+	}
+
+	// Clear session
+	if err := b.sessions.Delete(ctx, userID); err != nil {
+		log.Println("failed to clear session: ", err)
+	}
+
+	if session.EditID != nil {
+		return b.t(lang, "wizard.updated"), nil
+	}
+	return b.t(lang, "wizard.created"), nil
+}
+
+// describeConflict tells an admin whose edit was rejected what the question
+// was changed to in the meantime, and by whom. The version shown is kept in
+// the session for Overwrite.
+func (b *Bot) describeConflict(ctx context.Context, lang string, userID int64, session *PendingQuestionData) (string, *models.InlineKeyboardMarkup) {
+	current, err := b.repository.GetQuestionShallow(ctx, *session.EditID)
+	if err != nil {
+		return b.t(lang, "common.question_gone"), nil
+	}
+
+	session.ConflictVersion = current.Version
+	if err := b.sessions.Save(ctx, userID, session); err != nil {
+		log.Println("failed to save session: ", err)
+		return b.t(lang, "wizard.update_failed"), nil
+	}
+
+	changed := b.t(lang, "conflict.changed", current.ID)
+	if revisions, err := b.repository.ListRevisions(ctx, current.ID); err == nil && len(revisions) > 0 {
+		changed = b.t(lang, "conflict.changed_by", current.ID, b.revisionTitle(lang, revisions[0]))
+	}
+
+	text := changed + "\n\n" +
+		b.t(lang, "conflict.theirs", b.describeContent(lang, current.Text, current.Answer, current.FileType)) + "\n\n" +
+		b.t(lang, "conflict.yours", b.describeContent(lang, session.Text, session.Answer, session.FileType)) + "\n\n" +
+		b.t(lang, "conflict.hint")
+
+	return truncateText(text, maxMessageLength), &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{Text: b.t(lang, "conflict.overwrite"), CallbackData: stampCallback(wizardOverwrite)},
+			{Text: b.t(lang, "conflict.reopen"), CallbackData: wizardReopen},
+			{Text: b.t(lang, "common.cancel"), CallbackData: wizardCancel},
+		}},
+	}
+}
+
+// saveEdit writes an edited question together with its revision and audit
+// entry, all or nothing.
+func (b *Bot) saveEdit(ctx context.Context, userID int64, session *PendingQuestionData) error {
+	return b.repository.WithTx(ctx, func(tx BotRepository) error {
+		// Sessions saved before versions existed have none to check
+		if session.Version != 0 {
+			if err := tx.CheckQuestionVersion(ctx, *session.EditID, session.Version); err != nil {
+				return err
+			}
+		}
+
+		current, err := tx.GetQuestionShallow(ctx, *session.EditID)
+		if err != nil {
+			return err
+		}
+		if err := ensureBaselineRevision(ctx, tx, current.ID); err != nil {
+			return err
+		}
+
+		if err := tx.UpdateQuestion(ctx, current.ID, session.Text, session.Answer); err != nil {
+			return err
+		}
+		if err := tx.UpdateQuestionFile(ctx, current.ID, session.FileType, session.FileID); err != nil {
+			return err
+		}
+
+		var (
+			action   AuditAction
+			revision RevisionAction
+		)
+		switch {
+		case current.Text != session.Text || current.Answer != session.Answer:
+			action, revision = AuditQuestionEdit, RevisionUpdate
+		case current.FileType != session.FileType || current.FileID != session.FileID:
+			action, revision = AuditQuestionFile, RevisionFile
+		default:
+			return nil
+		}
+
+		if err := recordRevision(ctx, tx, current.ID, revision, userID); err != nil {
+			return err
+		}
+		updated, err := tx.GetQuestionShallow(ctx, current.ID)
+		if err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: action, QuestionID: current.ID}, current, updated)
+	})
+}
+
+// saveNew creates a question with its attachment, first revision and audit
+// entry, all or nothing, and marks the search gap it answers as resolved.
+func (b *Bot) saveNew(ctx context.Context, userID int64, session *PendingQuestionData) (int, error) {
+	var qID int
+	err := b.repository.WithTx(ctx, func(tx BotRepository) error {
+		var err error
+		if qID, err = tx.CreateQuestion(ctx, session.Lang, session.Text, session.Answer, session.FileType, session.FileID, session.ParentID); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, tx, qID, RevisionCreate, userID); err != nil {
+			return err
+		}
+		if session.Gap != "" {
+			if err := tx.ResolveUnansweredQuery(ctx, session.Lang, session.Gap); err != nil {
+				return err
+			}
+		}
+		created, err := tx.GetQuestionShallow(ctx, qID)
+		if err != nil {
+			return err
+		}
+		return addAuditEntry(ctx, tx, AuditEntry{ActorID: userID, Action: AuditQuestionCreate, QuestionID: qID}, nil, created)
+	})
+	return qID, err
+}
+
+// messageFile returns the type and Telegram file ID of an attached document or photo.
+func messageFile(msg *models.Message) (fileType, fileID string) {
+	if msg.Document != nil {
+		return fileTypeDoc, msg.Document.FileID
+	}
+	if len(msg.Photo) != 0 {
+		// The last size is the largest one
+		return fileTypePhoto, msg.Photo[len(msg.Photo)-1].FileID
+	}
+	return "", ""
+}
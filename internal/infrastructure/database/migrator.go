@@ -0,0 +1,299 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"qaBot/migration"
+)
+
+// Supported migration dialects. They match the database.driver config values.
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+var ErrUnknownDialect = errors.New("unknown migration dialect")
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations for one dialect and records the
+// applied versions in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator loads the embedded migrations for the given dialect.
+func NewMigrator(db *sql.DB, dialect string) (*Migrator, error) {
+	if dialect != DialectPostgres && dialect != DialectSQLite {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDialect, dialect)
+	}
+
+	migrations, err := loadMigrations(migration.FS, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionPart, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m *Migrator) placeholder(n int) string {
+	if m.dialect == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Status lists every known migration together with its applied time, if any.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := MigrationStatus{Migration: mg}
+		if at, ok := applied[mg.Version]; ok {
+			s.AppliedAt = &at
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in version order and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, mg := range pending {
+		insert := fmt.Sprintf("INSERT INTO schema_migrations (version, name) VALUES (%s, %s)", m.placeholder(1), m.placeholder(2))
+		if err := m.run(ctx, mg.Up, insert, mg.Version, mg.Name); err != nil {
+			return i, fmt.Errorf("migration %04d_%s up: %w", mg.Version, mg.Name, err)
+		}
+		log.Printf("Applied migration %04d_%s", mg.Version, mg.Name)
+	}
+
+	return len(pending), nil
+}
+
+// Down rolls back the given number of most recently applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rolledBack := 0
+	for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if mg.Down == "" {
+			return rolledBack, fmt.Errorf("migration %04d_%s has no down script", mg.Version, mg.Name)
+		}
+
+		del := fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %s", m.placeholder(1))
+		if err := m.run(ctx, mg.Down, del, mg.Version); err != nil {
+			return rolledBack, fmt.Errorf("migration %04d_%s down: %w", mg.Version, mg.Name, err)
+		}
+		log.Printf("Rolled back migration %04d_%s", mg.Version, mg.Name)
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}
+
+// run executes a migration script and its bookkeeping statement in one transaction.
+func (m *Migrator) run(ctx context.Context, script, bookkeeping string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.dialect == DialectSQLite {
+		if script, err = resolveAddColumnIfNotExists(ctx, tx, script); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+var addColumnIfNotExists = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+IF\s+NOT\s+EXISTS\s+(\w+)([^;]*);`)
+
+// resolveAddColumnIfNotExists rewrites the Postgres style
+// "ALTER TABLE ... ADD COLUMN IF NOT EXISTS" statements, which SQLite does not
+// support, into plain ADD COLUMN statements and drops those whose column
+// already exists. Databases created before the migrations may already have
+// some of the columns.
+func resolveAddColumnIfNotExists(ctx context.Context, tx *sql.Tx, script string) (string, error) {
+	matches := addColumnIfNotExists.FindAllStringSubmatchIndex(script, -1)
+	if len(matches) == 0 {
+		return script, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, mt := range matches {
+		table, column, rest := script[mt[2]:mt[3]], script[mt[4]:mt[5]], script[mt[6]:mt[7]]
+
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&exists)
+		if err != nil {
+			return "", fmt.Errorf("check column %s.%s: %w", table, column, err)
+		}
+
+		b.WriteString(script[last:mt[0]])
+		if !exists {
+			b.WriteString("ALTER TABLE " + table + " ADD COLUMN " + column + rest + ";")
+		}
+		last = mt[1]
+	}
+	b.WriteString(script[last:])
+
+	return b.String(), nil
+}
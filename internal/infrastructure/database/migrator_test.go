@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// The SQLite tests need FTS5 for the search migration:
+//
+//	go test -tags sqlite_fts5 ./internal/infrastructure/database
+
+// openTestSQLite opens an empty in-memory database. It keeps a single
+// connection, as every new connection would get its own empty database.
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := RequireFTS5(db); errors.Is(err, ErrNoFTS5) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigratorUpDownUp(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMigrator(openTestSQLite(t), DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(m.migrations)
+
+	assertPending := func(want int) {
+		t.Helper()
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != want {
+			t.Fatalf("pending = %d, want %d", len(pending), want)
+		}
+
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied := 0
+		for _, s := range statuses {
+			if s.AppliedAt != nil {
+				applied++
+			}
+		}
+		if len(statuses) != total || applied != total-want {
+			t.Fatalf("status: %d migrations with %d applied, want %d with %d", len(statuses), applied, total, total-want)
+		}
+	}
+
+	assertPending(total)
+
+	if n, err := m.Up(ctx); err != nil || n != total {
+		t.Fatalf("up = %d, %v; want %d", n, err, total)
+	}
+	assertPending(0)
+
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Fatalf("second up = %d, %v; want 0", n, err)
+	}
+
+	if n, err := m.Down(ctx, 2); err != nil || n != 2 {
+		t.Fatalf("down 2 = %d, %v; want 2", n, err)
+	}
+	assertPending(2)
+
+	if n, err := m.Down(ctx, total); err != nil || n != total-2 {
+		t.Fatalf("down all = %d, %v; want %d", n, err, total-2)
+	}
+	assertPending(total)
+
+	if n, err := m.Up(ctx); err != nil || n != total {
+		t.Fatalf("up again = %d, %v; want %d", n, err, total)
+	}
+	assertPending(0)
+}
+
+func TestMigratorUpgradesLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSQLite(t)
+
+	// The schema of databases created before the versioned migrations
+	_, err := db.Exec(`CREATE TABLE questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    text TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    parent_id INTEGER DEFAULT NULL, lang TEXT NOT NULL DEFAULT 'en',
+    FOREIGN KEY (parent_id) REFERENCES questions (id)
+);
+CREATE TABLE user_languages (
+    user_id INTEGER PRIMARY KEY,
+    lang TEXT NOT NULL
+);
+INSERT INTO questions (text, answer, lang) VALUES ('Question', 'Answer', 'ru');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		lang, fileID, fileType string
+		position, version      int
+	)
+	err = db.QueryRow("SELECT lang, file_id, file_type, position, version FROM questions WHERE text = 'Question'").
+		Scan(&lang, &fileID, &fileType, &position, &version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lang != "ru" || fileID != "" || fileType != "" || position != 1 || version != 1 {
+		t.Fatalf("got lang=%q file_id=%q file_type=%q position=%d version=%d", lang, fileID, fileType, position, version)
+	}
+}
+
+func TestMigratorSkipsExistingColumns(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSQLite(t)
+
+	m, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Forget the soft delete migration, whose columns stay in place
+	if _, err := db.Exec("DELETE FROM schema_migrations WHERE version = 6"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 1 {
+		t.Fatalf("up = %d, %v; want 1", n, err)
+	}
+}
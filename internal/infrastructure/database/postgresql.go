@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"log"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 type PostgresConfig struct {
@@ -56,6 +58,12 @@ func GetPostgresDB() *pgxpool.Pool {
 	return pgdb
 }
 
+// GetPostgresSQLDB returns a database/sql handle backed by the PostgreSQL pool,
+// for code that is shared with the SQLite backend such as the migrator.
+func GetPostgresSQLDB() *sql.DB {
+	return stdlib.OpenDBFromPool(pgdb)
+}
+
 func ClosePostgres() {
 	pgdb.Close()
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -16,9 +17,48 @@ func Initialize(dataSourceName string) {
 	if err != nil {
 		log.Fatalf("failed to open database: %v", err)
 	}
+
+	if err = db.Ping(); err != nil {
+		log.Fatalf("failed to ping SQLite: %v", err)
+	}
+
+	if err = RequireFTS5(db); err != nil {
+		log.Fatalf("unusable SQLite build: %v", err)
+	}
+
+	log.Println("Connected to SQLite")
+}
+
+// ErrNoFTS5 is returned by RequireFTS5 for SQLite builds without FTS5.
+var ErrNoFTS5 = errors.New("SQLite was built without FTS5, which question search needs; build with `-tags sqlite_fts5`")
+
+// RequireFTS5 checks that the linked SQLite library has the FTS5 module the
+// search migration creates its index with. go-sqlite3 only compiles it in
+// with the sqlite_fts5 build tag.
+func RequireFTS5(db *sql.DB) error {
+	var enabled bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrNoFTS5
+	}
+	return nil
+}
+
+// SQLiteDSN builds a data source name for the given database file with
+// foreign keys enabled and a busy timeout suitable for concurrent handlers.
+// Transactions take the write lock when they begin, so that one which reads
+// before writing waits for others instead of failing to upgrade its lock.
+func SQLiteDSN(path string) string {
+	return "file:" + path + "?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate"
 }
 
 // GetDB returns the database instance.
 func GetDB() *sql.DB {
 	return db
 }
+
+func Close() {
+	db.Close()
+}
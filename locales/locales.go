@@ -0,0 +1,9 @@
+// Package locales embeds the default message catalogs, one file per language.
+// Deployments may use their own directory instead, see languages.locales_dir
+// in the configuration.
+package locales
+
+import "embed"
+
+//go:embed *.yml
+var FS embed.FS
@@ -1,6 +0,0 @@
-ALTER TABLE questions ADD COLUMN lang TEXT NOT NULL DEFAULT 'en';
-
-CREATE TABLE IF NOT EXISTS user_languages (
-    user_id INTEGER PRIMARY KEY,
-    lang TEXT NOT NULL
-);
\ No newline at end of file
@@ -0,0 +1,10 @@
+// Package migration embeds the versioned SQL schema migrations.
+//
+// Migrations live in one directory per dialect (postgres, sqlite) and are
+// named <version>_<name>.up.sql / <version>_<name>.down.sql.
+package migration
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
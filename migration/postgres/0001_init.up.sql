@@ -11,4 +11,4 @@ CREATE TABLE IF NOT EXISTS questions (
 CREATE TABLE IF NOT EXISTS user_languages (
     user_id BIGINT PRIMARY KEY,
     lang TEXT NOT NULL
-);
\ No newline at end of file
+);
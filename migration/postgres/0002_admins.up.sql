@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS admins (
+    user_id BIGINT PRIMARY KEY,
+    role varchar(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
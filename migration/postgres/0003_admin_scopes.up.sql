@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS admin_scopes (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES admins(user_id) ON DELETE CASCADE,
+    lang TEXT NOT NULL DEFAULT '',
+    root_id INTEGER NOT NULL DEFAULT 0,
+    actions TEXT NOT NULL DEFAULT 'add,edit,delete'
+);
+
+CREATE INDEX IF NOT EXISTS admin_scopes_user_id_idx ON admin_scopes (user_id);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS admin_sessions (
+    user_id BIGINT PRIMARY KEY,
+    data TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
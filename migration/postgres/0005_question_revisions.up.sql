@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS question_revisions (
+    id SERIAL PRIMARY KEY,
+    question_id INTEGER NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    action varchar(20) NOT NULL,
+    text TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    file_type varchar(20) NOT NULL DEFAULT '',
+    file_id TEXT NOT NULL DEFAULT '',
+    author_id BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS question_revisions_question_id_idx ON question_revisions (question_id);
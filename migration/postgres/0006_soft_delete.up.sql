@@ -0,0 +1,5 @@
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS deleted_by BIGINT;
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS deleted_root_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS questions_deleted_root_id_idx ON questions (deleted_root_id) WHERE deleted_root_id IS NOT NULL;
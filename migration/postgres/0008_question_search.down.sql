@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS questions_search_vector_idx;
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS synonyms (
+    id SERIAL PRIMARY KEY,
+    lang TEXT NOT NULL,
+    term TEXT NOT NULL,
+    synonym TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (lang, term, synonym)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS unanswered_queries (
+    id BIGSERIAL PRIMARY KEY,
+    lang TEXT NOT NULL,
+    query TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS unanswered_queries_created_at_idx ON unanswered_queries (created_at);
+CREATE INDEX IF NOT EXISTS unanswered_queries_query_idx ON unanswered_queries (lang, query);
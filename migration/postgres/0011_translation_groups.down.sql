@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS questions_translation_group_idx;
+ALTER TABLE questions DROP COLUMN IF EXISTS translation_group;
+DROP SEQUENCE IF EXISTS translation_group_seq;
@@ -0,0 +1,6 @@
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+-- Keep the current order, which was by ID
+UPDATE questions SET position = id;
+
+CREATE INDEX IF NOT EXISTS questions_parent_position_idx ON questions (parent_id, position);
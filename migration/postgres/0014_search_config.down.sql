@@ -0,0 +1,12 @@
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE questions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    CASE WHEN lang = 'ru' THEN
+        setweight(to_tsvector('russian', text), 'A') || setweight(to_tsvector('russian', answer), 'B')
+    ELSE
+        setweight(to_tsvector('english', text), 'A') || setweight(to_tsvector('english', answer), 'B')
+    END
+) STORED;
+
+CREATE INDEX IF NOT EXISTS questions_search_vector_idx ON questions USING GIN (search_vector);
+
+DROP FUNCTION IF EXISTS question_search_config(TEXT);
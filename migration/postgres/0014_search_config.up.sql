@@ -0,0 +1,32 @@
+-- Stem each language with its own text search configuration. Languages
+-- without one, e.g. Kazakh, are indexed word by word instead of as English.
+CREATE OR REPLACE FUNCTION question_search_config(lang TEXT) RETURNS regconfig AS $$
+    SELECT CASE lang
+        WHEN 'da' THEN 'danish'
+        WHEN 'de' THEN 'german'
+        WHEN 'en' THEN 'english'
+        WHEN 'es' THEN 'spanish'
+        WHEN 'fi' THEN 'finnish'
+        WHEN 'fr' THEN 'french'
+        WHEN 'hu' THEN 'hungarian'
+        WHEN 'it' THEN 'italian'
+        WHEN 'nb' THEN 'norwegian'
+        WHEN 'nl' THEN 'dutch'
+        WHEN 'no' THEN 'norwegian'
+        WHEN 'pt' THEN 'portuguese'
+        WHEN 'ro' THEN 'romanian'
+        WHEN 'ru' THEN 'russian'
+        WHEN 'sv' THEN 'swedish'
+        WHEN 'tr' THEN 'turkish'
+        ELSE 'simple'
+    END::regconfig
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Dropping the column drops its index too
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE questions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(question_search_config(lang), text), 'A') ||
+    setweight(to_tsvector(question_search_config(lang), answer), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS questions_search_vector_idx ON questions USING GIN (search_vector);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    text TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    parent_id INTEGER DEFAULT NULL,
+    lang TEXT NOT NULL DEFAULT 'en',
+    file_id TEXT NOT NULL DEFAULT '',
+    file_type varchar(20) NOT NULL DEFAULT '',
+    FOREIGN KEY (parent_id) REFERENCES questions (id)
+);
+
+CREATE TABLE IF NOT EXISTS user_languages (
+    user_id INTEGER PRIMARY KEY,
+    lang TEXT NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS admin_scopes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    lang TEXT NOT NULL DEFAULT '',
+    root_id INTEGER NOT NULL DEFAULT 0,
+    actions TEXT NOT NULL DEFAULT 'add,edit,delete',
+    FOREIGN KEY (user_id) REFERENCES admins (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS admin_scopes_user_id_idx ON admin_scopes (user_id);
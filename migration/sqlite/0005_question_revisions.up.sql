@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS question_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    question_id INTEGER NOT NULL,
+    action varchar(20) NOT NULL,
+    text TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    file_type varchar(20) NOT NULL DEFAULT '',
+    file_id TEXT NOT NULL DEFAULT '',
+    author_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (question_id) REFERENCES questions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS question_revisions_question_id_idx ON question_revisions (question_id);
@@ -0,0 +1,6 @@
+DELETE FROM questions WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS questions_deleted_root_id_idx;
+ALTER TABLE questions DROP COLUMN deleted_root_id;
+ALTER TABLE questions DROP COLUMN deleted_by;
+ALTER TABLE questions DROP COLUMN deleted_at;
@@ -0,0 +1,26 @@
+-- Requires a binary built with -tags sqlite_fts5
+CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(
+    text,
+    answer,
+    content = 'questions',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_insert AFTER INSERT ON questions
+BEGIN
+    INSERT INTO questions_fts (rowid, text, answer) VALUES (new.id, new.text, new.answer);
+END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_delete AFTER DELETE ON questions
+BEGIN
+    INSERT INTO questions_fts (questions_fts, rowid, text, answer) VALUES ('delete', old.id, old.text, old.answer);
+END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_update AFTER UPDATE OF text, answer ON questions
+BEGIN
+    INSERT INTO questions_fts (questions_fts, rowid, text, answer) VALUES ('delete', old.id, old.text, old.answer);
+    INSERT INTO questions_fts (rowid, text, answer) VALUES (new.id, new.text, new.answer);
+END;
+
+INSERT INTO questions_fts (questions_fts) VALUES ('rebuild');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS questions_translation_group_idx;
+ALTER TABLE questions DROP COLUMN translation_group;
@@ -0,0 +1,3 @@
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS translation_group INTEGER;
+
+CREATE INDEX IF NOT EXISTS questions_translation_group_idx ON questions (translation_group) WHERE translation_group IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS questions_parent_position_idx;
+ALTER TABLE questions DROP COLUMN position;
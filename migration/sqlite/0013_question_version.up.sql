@@ -0,0 +1,3 @@
+-- Bumped whenever the text, answer or attachment of a question changes, so
+-- that concurrent edits can be detected
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
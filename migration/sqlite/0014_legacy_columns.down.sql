@@ -0,0 +1,2 @@
+-- The columns are part of the initial schema and are dropped with it
+SELECT 1;
@@ -0,0 +1,5 @@
+-- Databases created before the versioned migrations may have a questions
+-- table without these columns, which 0001 leaves alone
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS lang TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS file_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS file_type varchar(20) NOT NULL DEFAULT '';
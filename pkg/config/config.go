@@ -41,6 +41,30 @@ func GetString(key string) string {
 	return settings.String(key)
 }
 
+// GetStrings retrieves a list of string values from the configuration or returns nil if settings is nil.
+func GetStrings(key string) []string {
+	if settings == nil {
+		return nil
+	}
+	return settings.Strings(key)
+}
+
+// GetStringLists retrieves a map of string lists from the configuration or returns nil if settings is nil.
+func GetStringLists(key string) map[string][]string {
+	if settings == nil {
+		return nil
+	}
+	raw, ok := settings.Get(key).(map[string]any)
+	if !ok {
+		return nil
+	}
+	values := make(map[string][]string, len(raw))
+	for k := range raw {
+		values[k] = settings.Strings(key + "." + k)
+	}
+	return values
+}
+
 // GetInt retrieves an integer value from the configuration or returns 0 if settings is nil.
 func GetInt(key string) int {
 	if settings == nil {
@@ -65,6 +89,19 @@ func GetInt64(key string) int64 {
 	return settings.Int64(key)
 }
 
+// GetInt64s retrieves a list of int64 values from the configuration or returns nil if settings is nil.
+func GetInt64s(key string) []int64 {
+	if settings == nil {
+		return nil
+	}
+	ints := settings.Ints(key)
+	values := make([]int64, 0, len(ints))
+	for _, v := range ints {
+		values = append(values, int64(v))
+	}
+	return values
+}
+
 // GetFloat32 retrieves a float32 value from the configuration or returns 0.0 if settings is nil.
 func GetFloat32(key string) float32 {
 	if settings == nil {
@@ -0,0 +1,224 @@
+// Package i18n holds the message catalogs of the languages the bot speaks.
+// Every language has one catalog file, <code>.yml, <code>.yaml or
+// <code>.json, mapping message keys to texts. Nested maps are flattened into
+// dotted keys, so {search: {usage: ...}} defines "search.usage".
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// NameKey is the message holding the display name of a catalog's language.
+const NameKey = "language.name"
+
+var ErrNoLanguages = errors.New("no languages configured")
+
+// Language is a language the bot speaks.
+type Language struct {
+	Code string
+	Name string
+}
+
+// Catalog looks up messages by language and key. Messages missing from a
+// language are taken from its fallback chain, see Chain, and failing that
+// the key itself is returned.
+type Catalog struct {
+	languages []Language
+	fallback  string
+	fallbacks map[string][]string          // language -> languages to try next
+	messages  map[string]map[string]string // language -> key -> message
+}
+
+// Load reads the catalogs of the given language codes from fsys, in that
+// order. Without codes every catalog in fsys is loaded. The default language
+// must be among them; when empty the first language is the default.
+func Load(fsys fs.FS, codes []string, fallback string) (*Catalog, error) {
+	if len(codes) == 0 {
+		var err error
+		if codes, err = catalogCodes(fsys); err != nil {
+			return nil, err
+		}
+	}
+	if len(codes) == 0 {
+		return nil, ErrNoLanguages
+	}
+	if fallback == "" {
+		fallback = codes[0]
+	}
+
+	c := &Catalog{fallback: fallback, messages: make(map[string]map[string]string)}
+	for _, code := range codes {
+		messages, err := loadCatalog(fsys, code)
+		if err != nil {
+			return nil, err
+		}
+		name := messages[NameKey]
+		if name == "" {
+			name = code
+		}
+		c.languages = append(c.languages, Language{Code: code, Name: name})
+		c.messages[code] = messages
+	}
+
+	if !c.Has(fallback) {
+		return nil, fmt.Errorf("default language %q is not configured", fallback)
+	}
+	return c, nil
+}
+
+// catalogCodes lists the languages with a catalog file in fsys, sorted.
+func catalogCodes(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml" && ext != ".json") {
+			continue
+		}
+		codes = append(codes, strings.TrimSuffix(e.Name(), ext))
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+func loadCatalog(fsys fs.FS, code string) (map[string]string, error) {
+	for _, ext := range []string{".yml", ".yaml", ".json"} {
+		data, err := fs.ReadFile(fsys, code+ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var raw map[string]any
+		if ext == ".json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = yaml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse catalog %s: %w", code+ext, err)
+		}
+
+		messages := make(map[string]string)
+		flatten(messages, "", raw)
+		return messages, nil
+	}
+	return nil, fmt.Errorf("no catalog found for language %q", code)
+}
+
+func flatten(messages map[string]string, prefix string, raw map[string]any) {
+	for k, v := range raw {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(messages, key+".", v)
+		case string:
+			messages[key] = v
+		case nil:
+		default:
+			messages[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// SetFallbacks sets the languages tried, in order, when something is not
+// available in a language, e.g. {"kk": {"ru"}}. Languages without a catalog
+// may have fallbacks too, which maps them to configured ones in Match; the
+// fallbacks themselves must be configured.
+func (c *Catalog) SetFallbacks(fallbacks map[string][]string) error {
+	for lang, chain := range fallbacks {
+		for _, code := range chain {
+			if !c.Has(code) {
+				return fmt.Errorf("fallback %q of %q is not configured", code, lang)
+			}
+		}
+	}
+	c.fallbacks = fallbacks
+	return nil
+}
+
+// Chain returns the configured languages to try for lang, most preferred
+// first: lang itself, its fallbacks, then the default language.
+func (c *Catalog) Chain(lang string) []string {
+	var chain []string
+	for _, code := range append(append([]string{lang}, c.fallbacks[lang]...), c.fallback) {
+		if c.Has(code) && !slices.Contains(chain, code) {
+			chain = append(chain, code)
+		}
+	}
+	return chain
+}
+
+// Match maps a language tag such as Telegram's User.LanguageCode ("ru",
+// "en-US") to the closest configured language.
+func (c *Catalog) Match(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	return c.Chain(base)[0]
+}
+
+// Languages returns the configured languages in order.
+func (c *Catalog) Languages() []Language {
+	return c.languages
+}
+
+// Default returns the code of the default language.
+func (c *Catalog) Default() string {
+	return c.fallback
+}
+
+// Has reports whether a language is configured.
+func (c *Catalog) Has(code string) bool {
+	_, ok := c.messages[code]
+	return ok
+}
+
+// Name returns the display name of a language, or its code when unknown.
+func (c *Catalog) Name(code string) string {
+	for _, l := range c.languages {
+		if l.Code == code {
+			return l.Name
+		}
+	}
+	return code
+}
+
+// ByName finds a language by its display name.
+func (c *Catalog) ByName(name string) (Language, bool) {
+	for _, l := range c.languages {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// T returns the message for key in a language, formatted with args when any
+// are given.
+func (c *Catalog) T(lang, key string, args ...any) string {
+	msg := key
+	for _, code := range c.Chain(lang) {
+		if m, ok := c.messages[code][key]; ok {
+			msg = m
+			break
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+
+	fsys := fstest.MapFS{
+		"en.yml": {Data: []byte(`
+language:
+  name: English
+greeting: Hello, %s!
+only_en: English only
+`)},
+		"ru.yml": {Data: []byte(`
+language:
+  name: Русский
+greeting: Привет, %s!
+only_ru: Только по-русски
+`)},
+		"kk.json":   {Data: []byte(`{"language": {"name": "Қазақша"}, "greeting": "Сәлем, %s!"}`)},
+		"README.md": {Data: []byte("not a catalog")},
+	}
+
+	c, err := Load(fsys, nil, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uz has no catalog and is served in Russian
+	if err := c.SetFallbacks(map[string][]string{"kk": {"ru"}, "uz": {"ru"}}); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLoad(t *testing.T) {
+	c := testCatalog(t)
+
+	want := []Language{{"en", "English"}, {"kk", "Қазақша"}, {"ru", "Русский"}}
+	if got := c.Languages(); !slices.Equal(got, want) {
+		t.Errorf("Languages() = %v, want %v", got, want)
+	}
+	if got := c.Default(); got != "en" {
+		t.Errorf("Default() = %q, want en", got)
+	}
+
+	fsys := fstest.MapFS{"en.yml": {Data: []byte("greeting: Hello")}}
+	if _, err := Load(fsys, nil, "ru"); err == nil {
+		t.Error("Load() with an unconfigured default language succeeded")
+	}
+	if _, err := Load(fsys, []string{"en", "ru"}, ""); err == nil {
+		t.Error("Load() of a missing catalog succeeded")
+	}
+	if _, err := Load(fstest.MapFS{}, nil, ""); !errors.Is(err, ErrNoLanguages) {
+		t.Errorf("Load() without catalogs = %v, want %v", err, ErrNoLanguages)
+	}
+}
+
+func TestSetFallbacks(t *testing.T) {
+	c := testCatalog(t)
+	if err := c.SetFallbacks(map[string][]string{"kk": {"de"}}); err == nil {
+		t.Fatal("SetFallbacks() with an unconfigured fallback succeeded")
+	}
+	// The failed call keeps the previous fallbacks
+	if got, want := c.Chain("kk"), []string{"kk", "ru", "en"}; !slices.Equal(got, want) {
+		t.Fatalf("Chain(kk) = %q, want %q", got, want)
+	}
+}
+
+func TestChain(t *testing.T) {
+	c := testCatalog(t)
+
+	tests := []struct {
+		lang string
+		want []string
+	}{
+		{"kk", []string{"kk", "ru", "en"}},
+		{"ru", []string{"ru", "en"}},
+		{"en", []string{"en"}},
+		{"uz", []string{"ru", "en"}},
+		{"de", []string{"en"}},
+		{"", []string{"en"}},
+	}
+	for _, tt := range tests {
+		if got := c.Chain(tt.lang); !slices.Equal(got, tt.want) {
+			t.Errorf("Chain(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	c := testCatalog(t)
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"en", "en"},
+		{"en-US", "en"},
+		{"en-GB", "en"},
+		{"ru", "ru"},
+		{"RU-ru", "ru"},
+		{"kk-KZ", "kk"},
+		{"uz-Latn", "ru"},
+		{"de-DE", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		if got := c.Match(tt.tag); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	c := testCatalog(t)
+
+	tests := []struct {
+		lang string
+		key  string
+		args []any
+		want string
+	}{
+		{"kk", "greeting", []any{"Айгүл"}, "Сәлем, Айгүл!"},
+		{"kk", "only_ru", nil, "Только по-русски"},
+		{"kk", "only_en", nil, "English only"},
+		{"ru", "only_en", nil, "English only"},
+		{"en", "only_ru", nil, "only_ru"},
+		{"de", "greeting", []any{"Anna"}, "Hello, Anna!"},
+		{"kk", "missing.key", nil, "missing.key"},
+		// Without args, messages are returned verbatim
+		{"en", "greeting", nil, "Hello, %s!"},
+	}
+	for _, tt := range tests {
+		if got := c.T(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
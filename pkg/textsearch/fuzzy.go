@@ -0,0 +1,152 @@
+package textsearch
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	// MinSimilarity is the trigram similarity below which words are unrelated.
+	MinSimilarity = 0.3
+
+	// maxTypos is the edit distance accepted between words of typical length.
+	maxTypos = 2
+)
+
+// trigrams returns the set of three-letter sequences of a word, padded the way
+// pg_trgm does so that word boundaries count.
+func trigrams(word string) map[string]struct{} {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// Similarity returns the trigram similarity of two words, from 0 to 1.
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	x, y := trigrams(a), trigrams(b)
+
+	shared := 0
+	for t := range x {
+		if _, ok := y[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(x)+len(y)-shared)
+}
+
+// TextSimilarity scores how well the query words are covered by a text: the
+// average over the query words of their best similarity to a word of the text.
+func TextSimilarity(query []string, text string) float64 {
+	words := Words(text)
+	if len(query) == 0 || len(words) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, q := range query {
+		best := 0.0
+		for _, w := range words {
+			best = max(best, Similarity(q, w))
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
+
+// Levenshtein returns the edit distance between two words.
+func Levenshtein(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(y)]
+}
+
+// Vocabulary is the set of words occurring in the searchable texts, used to
+// correct misspelled query words.
+type Vocabulary struct {
+	words map[string]int // word -> number of occurrences
+	stems map[string]struct{}
+}
+
+func NewVocabulary() *Vocabulary {
+	return &Vocabulary{
+		words: make(map[string]int),
+		stems: make(map[string]struct{}),
+	}
+}
+
+// Add adds the words of a text.
+func (v *Vocabulary) Add(text string) {
+	for _, w := range Words(text) {
+		v.words[w]++
+		v.stems[Stem(w)] = struct{}{}
+	}
+}
+
+// Contains reports whether the word, or another form of it, occurs in the texts.
+func (v *Vocabulary) Contains(word string) bool {
+	if _, ok := v.words[word]; ok {
+		return true
+	}
+	_, ok := v.stems[Stem(word)]
+	return ok
+}
+
+// Suggestion is a vocabulary word close to a query word.
+type Suggestion struct {
+	Word  string
+	Score float64
+}
+
+// Closest returns up to limit vocabulary words resembling the given word, best
+// first. Candidates must share enough trigrams or be within a couple of typos.
+func (v *Vocabulary) Closest(word string, limit int) []Suggestion {
+	typos := min(maxTypos, utf8.RuneCountInString(word)/4)
+
+	var suggestions []Suggestion
+	for w := range v.words {
+		score := Similarity(word, w)
+		if score < MinSimilarity {
+			if typos == 0 || Levenshtein(word, w) > typos {
+				continue
+			}
+			score = MinSimilarity
+		}
+		suggestions = append(suggestions, Suggestion{Word: w, Score: score})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if v.words[a.Word] != v.words[b.Word] {
+			return v.words[a.Word] > v.words[b.Word]
+		}
+		return a.Word < b.Word
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
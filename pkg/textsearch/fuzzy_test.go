@@ -0,0 +1,102 @@
+package textsearch
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"convention", "convention", 1, 1},
+		{"convention", "konvention", 0.5, 0.6},
+		{"пытки", "пытка", 0.5, 0.5},
+		{"torture", "rights", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("Similarity(%q, %q) = %v, want %v to %v", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"torture", "tortrue", 2},
+		{"пытки", "пытка", 1},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVocabulary(t *testing.T) {
+	v := NewVocabulary()
+	v.Add("Конвенция против пыток")
+	v.Add("Convention against Torture")
+
+	for word, want := range map[string]bool{"конвенция": true, "конвенции": true, "tortures": true, "кнвенция": false, "rights": false} {
+		if got := v.Contains(word); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", word, got, want)
+		}
+	}
+
+	// Suggestions back "Did you mean" when a search finds nothing. Words sharing
+	// too few trigrams with the query are only suggested within a couple of
+	// typos, and then with the weakest score that still counts.
+	tests := []struct {
+		word  string
+		want  string // "" when nothing is close enough
+		score float64
+	}{
+		{"кнвенция", "конвенция", 0.58},
+		{"конвенцыя", "конвенция", 0.53},
+		{Transliterate("konvencia"), "конвенция", 1},
+		{"пытки", "пыток", 0.33},
+		{"torure", "torture", 0.5},
+		{"vonvemtion", "convention", MinSimilarity}, // two typos in ten letters
+		{"rtoture", "", 0},                          // two typos are too many in seven letters
+		{"convnetoin", "", 0},
+		{"konvencia", "", 0}, // Latin letters only resemble Latin words
+		{"xy", "", 0},
+	}
+	for _, tt := range tests {
+		got := v.Closest(tt.word, 1)
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("Closest(%q) = %v, want nothing", tt.word, got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0].Word != tt.want || got[0].Score < tt.score || got[0].Score > tt.score+0.01 {
+			t.Errorf("Closest(%q) = %v, want %q scored %.2f", tt.word, got, tt.want, tt.score)
+		}
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	const text = "Convention against Torture"
+
+	tests := []struct {
+		query   []string
+		similar bool // scores at least MinSimilarity
+	}{
+		{[]string{"torture"}, true},
+		{[]string{"tortre", "convntion"}, true},
+		{[]string{"rights"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := TextSimilarity(tt.query, text); (got >= MinSimilarity) != tt.similar {
+			t.Errorf("TextSimilarity(%q) = %v, want similar = %v", tt.query, got, tt.similar)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package textsearch
+
+import (
+	"strings"
+)
+
+// Phrase is a sequence of words that must appear together.
+type Phrase []string
+
+// Group matches when any of its phrases does.
+type Group []Phrase
+
+// Query matches when every group does. Words are matched as prefixes so that
+// other forms of a word are found too.
+type Query []Group
+
+// NewQuery builds a query requiring every word.
+func NewQuery(words []string) Query {
+	query := make(Query, 0, len(words))
+	for _, w := range words {
+		query = append(query, Group{Phrase{w}})
+	}
+	return query
+}
+
+// Words returns the first phrase of every group, i.e. the words as typed.
+func (q Query) Words() []string {
+	var words []string
+	for _, g := range q {
+		words = append(words, g[0]...)
+	}
+	return words
+}
+
+// TSQuery renders the query in Postgres to_tsquery syntax, e.g.
+// "(torture:* | ill:* <-> treatment:*) & convention:*".
+func (q Query) TSQuery() string {
+	return q.render(" & ", " | ", " <-> ", func(w string) string { return w + ":*" })
+}
+
+// FTS5 renders the query as an SQLite FTS5 match expression. Russian words are
+// stemmed here; English ones are left to the table's porter tokenizer.
+func (q Query) FTS5() string {
+	return q.render(" AND ", " OR ", " + ", func(w string) string {
+		if IsCyrillic(w) {
+			w = Stem(w)
+		}
+		return `"` + w + `"*`
+	})
+}
+
+func (q Query) render(and, or, next string, word func(string) string) string {
+	groups := make([]string, 0, len(q))
+	for _, g := range q {
+		phrases := make([]string, 0, len(g))
+		for _, p := range g {
+			words := make([]string, 0, len(p))
+			for _, w := range p {
+				words = append(words, word(w))
+			}
+			phrases = append(phrases, strings.Join(words, next))
+		}
+
+		if len(phrases) == 1 {
+			groups = append(groups, phrases[0])
+		} else {
+			groups = append(groups, "("+strings.Join(phrases, or)+")")
+		}
+	}
+	return strings.Join(groups, and)
+}
+
+// Thesaurus maps terms to their synonyms. Terms are compared by stems, so a
+// synonym registered for "torture" also applies to "tortures".
+type Thesaurus struct {
+	synonyms map[string][]Phrase // stemmed term -> synonyms
+	longest  int                 // words in the longest term
+}
+
+func NewThesaurus() *Thesaurus {
+	return &Thesaurus{synonyms: make(map[string][]Phrase)}
+}
+
+// Add registers term and synonym as synonyms of each other.
+func (t *Thesaurus) Add(term, synonym string) {
+	a, b := Phrase(Words(term)), Phrase(Words(synonym))
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	t.add(a, b)
+	t.add(b, a)
+}
+
+func (t *Thesaurus) add(term, synonym Phrase) {
+	key := stemKey(term)
+	t.synonyms[key] = append(t.synonyms[key], synonym)
+	t.longest = max(t.longest, len(term))
+}
+
+func stemKey(words []string) string {
+	stems := make([]string, len(words))
+	for i, w := range words {
+		stems[i] = Stem(w)
+	}
+	return strings.Join(stems, " ")
+}
+
+// Expand builds a query from words, letting every term found in the
+// thesaurus also match its synonyms. Multi-word terms are matched greedily.
+func (t *Thesaurus) Expand(words []string) Query {
+	var query Query
+	for i := 0; i < len(words); {
+		n, synonyms := t.lookup(words[i:])
+		group := Group{Phrase(words[i : i+n])}
+
+		seen := map[string]bool{stemKey(group[0]): true}
+		for _, s := range synonyms {
+			if key := stemKey(s); !seen[key] {
+				seen[key] = true
+				group = append(group, s)
+			}
+		}
+
+		query = append(query, group)
+		i += n
+	}
+	return query
+}
+
+// lookup finds the longest term at the start of words, returning its length
+// in words and its synonyms. Unknown words have length 1 and no synonyms.
+func (t *Thesaurus) lookup(words []string) (int, []Phrase) {
+	for n := min(t.longest, len(words)); n > 0; n-- {
+		if synonyms, ok := t.synonyms[stemKey(words[:n])]; ok {
+			return n, synonyms
+		}
+	}
+	return 1, nil
+}
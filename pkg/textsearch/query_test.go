@@ -0,0 +1,74 @@
+package textsearch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestThesaurusExpand(t *testing.T) {
+	th := NewThesaurus()
+	th.Add("torture", "ill treatment")
+	th.Add("пытки", "истязания")
+
+	tests := []struct {
+		query string
+		ts    string
+		fts5  string
+	}{
+		{
+			query: "convention",
+			ts:    "convention:*",
+			fts5:  `"convention"*`,
+		},
+		{
+			// Terms match by stem, and the words as typed come first
+			query: "tortures convention",
+			ts:    "(tortures:* | ill:* <-> treatment:*) & convention:*",
+			fts5:  `("tortures"* OR "ill"* + "treatment"*) AND "convention"*`,
+		},
+		{
+			// Synonyms apply both ways, multi-word terms included
+			query: "ill treatment of children",
+			ts:    "(ill:* <-> treatment:* | torture:*) & of:* & children:*",
+			fts5:  `("ill"* + "treatment"* OR "torture"*) AND "of"* AND "children"*`,
+		},
+		{
+			// Russian words are stemmed for FTS5, which has no Russian tokenizer
+			query: "Пытками детей",
+			ts:    "(пытками:* | истязания:*) & детей:*",
+			fts5:  `("пытк"* OR "истязан"*) AND "дет"*`,
+		},
+	}
+	for _, tt := range tests {
+		q := th.Expand(Words(tt.query))
+		if got := q.TSQuery(); got != tt.ts {
+			t.Errorf("Expand(%q).TSQuery() = %q, want %q", tt.query, got, tt.ts)
+		}
+		if got := q.FTS5(); got != tt.fts5 {
+			t.Errorf("Expand(%q).FTS5() = %q, want %q", tt.query, got, tt.fts5)
+		}
+		if got := q.Words(); !slices.Equal(got, Words(tt.query)) {
+			t.Errorf("Expand(%q).Words() = %q, want the query words", tt.query, got)
+		}
+	}
+}
+
+func TestThesaurusExpandSkipsDuplicates(t *testing.T) {
+	th := NewThesaurus()
+	th.Add("torture", "ill treatment")
+	th.Add("tortures", "ill treatment")
+
+	if got, want := th.Expand([]string{"torture"}).TSQuery(), "(torture:* | ill:* <-> treatment:*)"; got != want {
+		t.Fatalf("TSQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestNewQuery(t *testing.T) {
+	q := NewQuery([]string{"пытки", "convention"})
+	if got, want := q.TSQuery(), "пытки:* & convention:*"; got != want {
+		t.Errorf("TSQuery() = %q, want %q", got, want)
+	}
+	if got, want := q.FTS5(), `"пытк"* AND "convention"*`; got != want {
+		t.Errorf("FTS5() = %q, want %q", got, want)
+	}
+}
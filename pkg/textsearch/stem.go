@@ -0,0 +1,79 @@
+package textsearch
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// minStemLength keeps stemming from reducing short words to nothing.
+const minStemLength = 3
+
+// Suffixes are tried longest first (see init); the first one leaving a long
+// enough stem wins.
+var (
+	russianSuffixes = []string{
+		// participles and adjectives
+		"ующими", "ающими", "яющими",
+		"ующий", "ающий", "яющий", "ованный", "ованная", "ованное", "ованные",
+		"ейшая", "ейшее", "ейший", "ейшие",
+		"ыми", "ими", "ого", "его", "ому", "ему", "ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "ой", "ую", "юю",
+		// verbs
+		"ировать", "овать", "евать", "ться", "тся", "ить", "ать", "ять", "еть", "уть",
+		"ала", "ало", "али", "ила", "ило", "или", "ует", "уют", "ешь", "ете", "ет", "ют", "ут", "ат", "ят", "ит",
+		// nouns
+		"ениями", "ениях", "ением", "ениям", "ений", "ения", "ение", "ении",
+		"остями", "остях", "остью", "ости", "ость",
+		"иями", "иях", "ией", "ием", "иям",
+		"ами", "ями", "ах", "ях", "ам", "ям", "ом", "ем", "ев", "ов", "ей",
+		"ия", "ие", "ии", "ью",
+		"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+	}
+
+	reflexiveSuffixes = []string{"ся", "сь"}
+
+	englishSuffixes = []string{
+		"ational", "ization", "fulness", "ousness", "iveness",
+		"ations", "ation", "ments", "ment", "ness", "ings", "ing", "ities", "ity",
+		"ies", "ied", "ers", "er", "ed", "es", "ly", "s",
+	}
+)
+
+func init() {
+	for _, suffixes := range [][]string{russianSuffixes, englishSuffixes} {
+		sort.SliceStable(suffixes, func(i, j int) bool {
+			return utf8.RuneCountInString(suffixes[i]) > utf8.RuneCountInString(suffixes[j])
+		})
+	}
+}
+
+// Stem reduces a lower-cased word to an approximate stem with a light
+// suffix-stripping stemmer: Russian rules for Cyrillic words, English rules
+// otherwise. It is deliberately aggressive, since stems are matched as prefixes.
+func Stem(word string) string {
+	if IsCyrillic(word) {
+		return stripSuffix(stripSuffix(word, reflexiveSuffixes), russianSuffixes)
+	}
+
+	stem := stripSuffix(word, englishSuffixes)
+	n := len(stem)
+	switch {
+	case n > minStemLength && stem[n-1] == 'e':
+		// "torture" and "tortures" share "tortur"
+		stem = stem[:n-1]
+	case n > minStemLength && stem != word && stem[n-1] == stem[n-2] && !strings.ContainsRune("lsz", rune(stem[n-1])):
+		// "running" -> "runn" -> "run"
+		stem = stem[:n-1]
+	}
+	return stem
+}
+
+func stripSuffix(word string, suffixes []string) string {
+	runes := utf8.RuneCountInString(word)
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && runes-utf8.RuneCountInString(suffix) >= minStemLength {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
@@ -0,0 +1,35 @@
+package textsearch
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Russian
+		{"конвенция", "конвенц"},
+		{"конвенции", "конвенц"},
+		{"пытки", "пытк"},
+		{"пытками", "пытк"},
+		{"права", "прав"},
+		{"правах", "прав"},
+		{"защищающий", "защищ"},
+		{"дом", "дом"},
+		// English
+		{"torture", "tortur"},
+		{"tortures", "tortur"},
+		{"tortured", "tortur"},
+		{"torturing", "tortur"},
+		{"running", "run"},
+		{"conventions", "convention"},
+		{"rights", "right"},
+		{"nationalization", "national"},
+		{"is", "is"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package textsearch holds the language helpers behind question search:
+// tokenizing, stemming, transliteration, fuzzy matching and synonym expansion.
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words splits text into lower-cased words, dropping punctuation and search
+// operators. "ё" is folded into "е" as Russian texts use them interchangeably.
+func Words(text string) []string {
+	text = strings.NewReplacer("ё", "е", "Ё", "е").Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsCyrillic reports whether the word contains Cyrillic letters.
+func IsCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLatin reports whether the word consists of Latin letters only.
+func isLatin(word string) bool {
+	for _, r := range word {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// translit maps Latin spellings to Cyrillic, longest sequences first.
+var translit = strings.NewReplacer(
+	"shch", "щ",
+	"sch", "щ",
+	"zh", "ж",
+	"kh", "х",
+	"ts", "ц",
+	"ch", "ч",
+	"sh", "ш",
+	"yu", "ю",
+	"ju", "ю",
+	"ya", "я",
+	"ja", "я",
+	"yo", "е",
+	"ye", "е",
+	"ia", "ия",
+	"a", "а",
+	"b", "б",
+	"c", "ц",
+	"d", "д",
+	"e", "е",
+	"f", "ф",
+	"g", "г",
+	"h", "х",
+	"i", "и",
+	"j", "й",
+	"k", "к",
+	"l", "л",
+	"m", "м",
+	"n", "н",
+	"o", "о",
+	"p", "п",
+	"q", "к",
+	"r", "р",
+	"s", "с",
+	"t", "т",
+	"u", "у",
+	"v", "в",
+	"w", "в",
+	"x", "кс",
+	"y", "ы",
+	"z", "з",
+)
+
+// Transliterate spells a Latin word in Cyrillic, e.g. "pytki" becomes "пытки".
+// Words with other characters are returned unchanged.
+func Transliterate(word string) string {
+	if !isLatin(word) {
+		return word
+	}
+	return translit.Replace(word)
+}
@@ -0,0 +1,45 @@
+package textsearch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	got := Words("Конвенция ООН: «пытки» и Ёлка — torture-free, 2024!")
+	want := []string{"конвенция", "оон", "пытки", "и", "елка", "torture", "free", "2024"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Words() = %q, want %q", got, want)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"konvencia", "конвенция"},
+		{"pytki", "пытки"},
+		{"prava", "права"},
+		{"zhaloba", "жалоба"},
+		{"shchit", "щит"},
+		{"yozh", "еж"},
+		// Only words of Latin letters are transliterated
+		{"пытки", "пытки"},
+		{"ok1", "ok1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Transliterate(tt.word); got != tt.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestIsCyrillic(t *testing.T) {
+	for word, want := range map[string]bool{"пытки": true, "torture": false, "2024": false, "Пытки": true} {
+		if got := IsCyrillic(word); got != want {
+			t.Errorf("IsCyrillic(%q) = %v, want %v", word, got, want)
+		}
+	}
+}